@@ -0,0 +1,468 @@
+// Package sensitivefields defines an Analyzer that reports struct fields and
+// proto fields which are likely sensitive but are logged by logjson as is.
+package sensitivefields
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ethanvc/logjson"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const doc = `report sensitive fields logged by logjson as is
+
+The sensitivefields analyzer reports exported struct fields whose names look
+sensitive, such as Password or IdNumber, but have no log tag and no
+MarshalLogJSON method of their own, such as logjson.Secret, and proto fields
+of generated messages with such names but without log_json options. It also
+reports log tags and log_json options which are not valid rules, or whose rule
+has no effect on the type of the field, such as md5 on an int.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "sensitivefields",
+	Doc:      doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// DefaultNames is the default value of the -names flag.
+const DefaultNames = "password,passwd,pwd,secret,token,apikey,accesskey,privatekey,credential,idnumber,idcard,ssn,cardnumber,cvv,pin"
+
+var names string
+
+func init() {
+	Analyzer.Flags.StringVar(&names, "names", DefaultNames, "comma separated list of sensitive words in field names")
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	c := &checker{
+		pass:       pass,
+		names:      strings.Split(names, ","),
+		protoTypes: collectProtoMessages(pass),
+	}
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	// typeNames are the names of the struct types, anonymous structs have no
+	// names.
+	typeNames := make(map[*ast.StructType]string)
+	inspect.Preorder([]ast.Node{(*ast.TypeSpec)(nil), (*ast.StructType)(nil)}, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.TypeSpec:
+			if st, ok := n.Type.(*ast.StructType); ok {
+				typeNames[st] = n.Name.Name
+			}
+		case *ast.StructType:
+			for _, field := range n.Fields.List {
+				c.checkField(typeNames[n], field)
+			}
+		}
+	})
+	return nil, nil
+}
+
+type checker struct {
+	pass  *analysis.Pass
+	names []string
+	// protoTypes are the messages of the proto files generated in the
+	// package.
+	protoTypes *protoMessages
+}
+
+type protoMessages struct {
+	// byGoName is keyed by the names of the Go types.
+	byGoName map[string]*protoMessage
+	// byFullName is keyed by the full names with a leading dot, like the
+	// type names of fields.
+	byFullName map[string]*protoMessage
+}
+
+type protoMessage struct {
+	desc *descriptorpb.DescriptorProto
+	// defaultRule is the log_json_default option of the message.
+	defaultRule string
+	// messageRule is the log_json_message option of the message.
+	messageRule string
+}
+
+func (c *checker) checkField(typeName string, field *ast.Field) {
+	var tag reflect.StructTag
+	if field.Tag != nil {
+		s, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			return
+		}
+		tag = reflect.StructTag(s)
+	}
+	if protoTag, ok := tag.Lookup("protobuf"); ok {
+		c.checkProtoField(typeName, field, protoTag)
+		return
+	}
+	for _, name := range field.Names {
+		if !name.IsExported() {
+			continue
+		}
+		rule, ok := tag.Lookup("log")
+		if !ok {
+			if c.isSensitive(name.Name) && !hasLogMarshaler(c.pass.TypesInfo.TypeOf(field.Type)) {
+				c.pass.Reportf(name.Pos(), "field %s looks sensitive but has no log tag", name.Name)
+			}
+			continue
+		}
+		if !logjson.ValidLogRule(rule) {
+			c.pass.Reportf(name.Pos(), "field %s has invalid log tag %q", name.Name, rule)
+			continue
+		}
+		t := c.pass.TypesInfo.TypeOf(field.Type)
+		if t != nil && !ruleApplies(rule, t) {
+			c.pass.Reportf(name.Pos(), "log rule %q has no effect on field %s of type %s", rule, name.Name, t)
+		}
+	}
+}
+
+func (c *checker) checkProtoField(typeName string, field *ast.Field, protoTag string) {
+	if len(field.Names) != 1 {
+		return
+	}
+	pos := field.Names[0].Pos()
+	msg := c.lookupProtoMessage(typeName)
+	if msg == nil {
+		return
+	}
+	fd := findProtoField(msg.desc, protoTag)
+	if fd == nil {
+		return
+	}
+	name := msg.desc.GetName() + "." + fd.GetName()
+	singular := fd.GetLabel() != descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	isString := fd.GetType() == descriptorpb.FieldDescriptorProto_TYPE_STRING && singular
+	isBytes := fd.GetType() == descriptorpb.FieldDescriptorProto_TYPE_BYTES && singular
+	opts := fd.GetOptions()
+	hasRule := false
+	if rule, ok := proto.GetExtension(opts, logjson.E_LogJson).(string); ok && rule != "" {
+		hasRule = true
+		if !logjson.ValidLogRule(rule) {
+			c.pass.Reportf(pos, "proto field %s has invalid log_json option %q", name, rule)
+		} else if !protoRuleApplies(rule, isString, isBytes) {
+			c.pass.Reportf(pos, "log rule %q has no effect on proto field %s of type %s", rule, name, protoTypeName(fd))
+		}
+	}
+	if proto.HasExtension(opts, logjson.E_LogJsonRule) {
+		hasRule = true
+		rule, _ := proto.GetExtension(opts, logjson.E_LogJsonRule).(*logjson.LogJsonRule)
+		switch rule.GetAction() {
+		case logjson.LogJsonRule_ACTION_HASH, logjson.LogJsonRule_ACTION_MASK:
+			if !isString {
+				c.pass.Reportf(pos, "log rule %s has no effect on proto field %s of type %s", rule.GetAction(), name, protoTypeName(fd))
+			}
+		}
+	}
+	if fieldMsg := c.protoTypes.byFullName[fd.GetTypeName()]; fieldMsg != nil && fieldMsg.messageRule != "" {
+		hasRule = true
+	}
+	if !hasRule && msg.defaultRule == "" && msg.messageRule == "" && c.isSensitive(fd.GetName()) {
+		c.pass.Reportf(pos, "proto field %s looks sensitive but has no log_json option", name)
+	}
+}
+
+// lookupProtoMessage returns the message of the Go type typeName, the message
+// containing the oneof field is returned for oneof wrapper types.
+func (c *checker) lookupProtoMessage(typeName string) *protoMessage {
+	for {
+		if msg, ok := c.protoTypes.byGoName[typeName]; ok {
+			return msg
+		}
+		i := strings.LastIndexByte(typeName, '_')
+		if i <= 0 {
+			return nil
+		}
+		typeName = typeName[:i]
+	}
+}
+
+func findProtoField(desc *descriptorpb.DescriptorProto, protoTag string) *descriptorpb.FieldDescriptorProto {
+	for _, part := range strings.Split(protoTag, ",") {
+		if name, ok := strings.CutPrefix(part, "name="); ok {
+			for _, fd := range desc.GetField() {
+				if fd.GetName() == name {
+					return fd
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func protoTypeName(fd *descriptorpb.FieldDescriptorProto) string {
+	name := strings.ToLower(strings.TrimPrefix(fd.GetType().String(), "TYPE_"))
+	if fd.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+		return "repeated " + name
+	}
+	return name
+}
+
+// hasLogMarshaler reports whether t or *t decides its own output by
+// MarshalLogJSON or MarshalLogJSONState, such as logjson.Secret.
+func hasLogMarshaler(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	if _, ok := t.Underlying().(*types.Pointer); !ok && !types.IsInterface(t) {
+		t = types.NewPointer(t)
+	}
+	mset := types.NewMethodSet(t)
+	return mset.Lookup(nil, "MarshalLogJSON") != nil || mset.Lookup(nil, "MarshalLogJSONState") != nil
+}
+
+// ruleApplies reports whether the valid rule changes the output of fields of
+// type t, bytes rules apply to byte slices and other rules except omit apply
+// to strings.
+func ruleApplies(rule string, t types.Type) bool {
+	if rule == "omit" {
+		return true
+	}
+	if isBytesRule(rule) {
+		return isBytes(t)
+	}
+	if rule == "json" {
+		return isBytes(t) || isString(t)
+	}
+	if isEnumRule(rule) {
+		return isEnumOrEnumPointer(t)
+	}
+	return isStringOrStringPointer(t)
+}
+
+func isBytes(t types.Type) bool {
+	s, ok := t.Underlying().(*types.Slice)
+	if !ok {
+		return false
+	}
+	b, ok := s.Elem().Underlying().(*types.Basic)
+	return ok && b.Kind() == types.Uint8
+}
+
+func isString(t types.Type) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsString != 0
+}
+
+// protoRuleApplies is like ruleApplies for proto fields.
+func protoRuleApplies(rule string, isString, isBytes bool) bool {
+	switch {
+	case rule == "omit":
+		return true
+	case isBytesRule(rule):
+		return isBytes
+	case rule == "json":
+		return isString || isBytes
+	case isEnumRule(rule):
+		return false
+	}
+	return isString
+}
+
+func isBytesRule(rule string) bool {
+	return strings.HasPrefix(rule, "bytes=")
+}
+
+func isEnumRule(rule string) bool {
+	return strings.HasPrefix(rule, "enum=")
+}
+
+// isEnumOrEnumPointer reports whether t or the element of t is a named bool,
+// number or string type with a String method.
+func isEnumOrEnumPointer(t types.Type) bool {
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if _, ok := t.(*types.Named); !ok {
+		return false
+	}
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok || b.Info()&(types.IsBoolean|types.IsString|types.IsInteger|types.IsFloat) == 0 {
+		return false
+	}
+	return types.NewMethodSet(types.NewPointer(t)).Lookup(nil, "String") != nil
+}
+
+func isStringOrStringPointer(t types.Type) bool {
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsString != 0
+}
+
+// isSensitive reports whether the words of name contain one of c.names, a
+// plural of the last word matches too. "AccessToken" and "user_password"
+// match, "Tokenizer" does not.
+func (c *checker) isSensitive(name string) bool {
+	words := splitWords(name)
+	for i := range words {
+		joined := ""
+		for j := i; j < len(words); j++ {
+			joined += words[j]
+			for _, s := range c.names {
+				if s != "" && (joined == s || joined == s+"s") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// splitWords splits an identifier in camel case or snake case into lower case
+// words, "IDNumber" is split into "id" and "number".
+func splitWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i := 0; i <= len(runes); i++ {
+		boundary := i == len(runes) || runes[i] == '_' || runes[i] == '-'
+		if !boundary && i > start && unicode.IsUpper(runes[i]) {
+			prevUpper := unicode.IsUpper(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			boundary = !prevUpper || nextLower
+		}
+		if !boundary {
+			continue
+		}
+		if i > start {
+			words = append(words, strings.ToLower(string(runes[start:i])))
+		}
+		start = i
+		if i < len(runes) && (runes[i] == '_' || runes[i] == '-') {
+			start = i + 1
+		}
+	}
+	return words
+}
+
+// collectProtoMessages parses the raw descriptors of the proto files
+// generated by protoc-gen-go in the package.
+func collectProtoMessages(pass *analysis.Pass) *protoMessages {
+	result := &protoMessages{
+		byGoName:   make(map[string]*protoMessage),
+		byFullName: make(map[string]*protoMessage),
+	}
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || (gen.Tok != token.VAR && gen.Tok != token.CONST) {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, name := range vs.Names {
+					if !strings.HasPrefix(name.Name, "file_") || !strings.HasSuffix(name.Name, "_rawDesc") || i >= len(vs.Values) {
+						continue
+					}
+					raw, ok := constantBytes(pass, vs.Values[i])
+					if !ok {
+						continue
+					}
+					fd := &descriptorpb.FileDescriptorProto{}
+					if err := proto.Unmarshal(raw, fd); err != nil {
+						continue
+					}
+					for _, msg := range fd.GetMessageType() {
+						result.add(fd.GetPackage(), "", msg)
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+// add adds desc and its nested messages, prefix is the name of the message
+// containing desc.
+func (m *protoMessages) add(pkg string, prefix string, desc *descriptorpb.DescriptorProto) {
+	if desc.GetOptions().GetMapEntry() {
+		return
+	}
+	name := prefix + desc.GetName()
+	msg := &protoMessage{desc: desc}
+	msg.defaultRule, _ = proto.GetExtension(desc.GetOptions(), logjson.E_LogJsonDefault).(string)
+	msg.messageRule, _ = proto.GetExtension(desc.GetOptions(), logjson.E_LogJsonMessage).(string)
+	m.byGoName[goCamelCase(name)] = msg
+	fullName := "." + name
+	if pkg != "" {
+		fullName = "." + pkg + fullName
+	}
+	m.byFullName[fullName] = msg
+	for _, nested := range desc.GetNestedType() {
+		m.add(pkg, name+".", nested)
+	}
+}
+
+// constantBytes returns the value of a []byte literal or a string constant.
+func constantBytes(pass *analysis.Pass, expr ast.Expr) ([]byte, bool) {
+	if tv, ok := pass.TypesInfo.Types[expr]; ok && tv.Value != nil && tv.Value.Kind() == constant.String {
+		return []byte(constant.StringVal(tv.Value)), true
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil, false
+	}
+	raw := make([]byte, 0, len(lit.Elts))
+	for _, elt := range lit.Elts {
+		tv, ok := pass.TypesInfo.Types[elt]
+		if !ok || tv.Value == nil {
+			return nil, false
+		}
+		b, ok := constant.Uint64Val(tv.Value)
+		if !ok {
+			return nil, false
+		}
+		raw = append(raw, byte(b))
+	}
+	return raw, true
+}
+
+// goCamelCase returns the Go name of a proto message name relative to its
+// package, like protoc-gen-go does.
+func goCamelCase(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '.' && i+1 < len(s) && isASCIILower(s[i+1]):
+		case c == '.':
+			b = append(b, '_')
+		case c == '_' && (i == 0 || s[i-1] == '.'):
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(s) && isASCIILower(s[i+1]):
+		case isASCIIDigit(c):
+			b = append(b, c)
+		default:
+			if isASCIILower(c) {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(s) && isASCIILower(s[i+1]); i++ {
+				b = append(b, s[i+1])
+			}
+		}
+	}
+	return string(b)
+}
+
+func isASCIILower(c byte) bool {
+	return 'a' <= c && c <= 'z'
+}
+
+func isASCIIDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
@@ -0,0 +1,18 @@
+package sensitivefields
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "a")
+}
+
+func TestSplitWords(t *testing.T) {
+	require.Equal(t, []string{"id", "number"}, splitWords("IDNumber"))
+	require.Equal(t, []string{"user", "password"}, splitWords("user_password"))
+	require.Equal(t, []string{"access", "token", "v2"}, splitWords("AccessTokenV2"))
+}
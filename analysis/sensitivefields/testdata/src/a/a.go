@@ -0,0 +1,36 @@
+package a
+
+import "github.com/go-json-experiment/json/jsontext"
+
+type Request struct {
+	UserName    string
+	Password    string // want `field Password looks sensitive but has no log tag`
+	AccessToken string `log:"md5"`
+	IDNumber    string // want `field IDNumber looks sensitive but has no log tag`
+	api_key     string
+	Tokenizer   string
+	PinCodes    []string // want `field PinCodes looks sensitive but has no log tag`
+	Secret      string   `log:"hash"` // want `field Secret has invalid log tag "hash"`
+	Pin         int      `log:"md5"`  // want `log rule "md5" has no effect on field Pin of type int`
+	Phone       *Phone   `log:"mask=3:4"`
+	Credential  []byte   `log:"omit"`
+	Level       *Level   `log:"enum=both"`
+	Kind        int      `log:"enum=name"` // want `log rule "enum=name" has no effect on field Kind of type int`
+	ApiSecret   Secret
+	OldPassword *Secret
+	Nested      struct {
+		ApiKey string // want `field ApiKey looks sensitive but has no log tag`
+	}
+}
+
+type Phone string
+
+type Level int
+
+func (l Level) String() string { return "" }
+
+type Secret struct {
+	value string
+}
+
+func (s *Secret) MarshalLogJSON(enc *jsontext.Encoder) {}
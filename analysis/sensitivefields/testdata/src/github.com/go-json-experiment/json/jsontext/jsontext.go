@@ -0,0 +1,3 @@
+package jsontext
+
+type Encoder struct{}
@@ -0,0 +1,120 @@
+package logjson
+
+import (
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+// AddDecimalType makes j write values of T as exact JSON numbers with the
+// decimal text returned by text, for decimal types which cannot implement
+// DecimalMarshaler, such as:
+//
+//	logjson.AddDecimalType(j, decimal.Decimal.String)
+//
+// *big.Int, *big.Float and *big.Rat are supported without AddDecimalType.
+func AddDecimalType[T any](j *LogJson, text func(T) string) {
+	j.mux.Lock()
+	if j.decimalTypes == nil {
+		j.decimalTypes = make(map[reflect.Type]func(v reflect.Value) string)
+	}
+	j.decimalTypes[reflect.TypeFor[T]()] = func(v reflect.Value) string {
+		return text(v.Interface().(T))
+	}
+	j.mux.Unlock()
+	j.resetHandlerItems()
+}
+
+var bigNumberTexts = map[reflect.Type]func(v reflect.Value) string{
+	reflect.TypeFor[*big.Int](): func(v reflect.Value) string {
+		return v.Interface().(*big.Int).String()
+	},
+	reflect.TypeFor[*big.Float](): func(v reflect.Value) string {
+		return v.Interface().(*big.Float).Text('g', -1)
+	},
+	reflect.TypeFor[*big.Rat](): func(v reflect.Value) string {
+		return ratText(v.Interface().(*big.Rat))
+	},
+}
+
+// getDecimalText returns the func converting values of t to decimal text, or
+// nil when t is not a decimal type.
+func (j *LogJson) getDecimalText(t reflect.Type) func(v reflect.Value) string {
+	if text, ok := bigNumberTexts[t]; ok {
+		return text
+	}
+	j.mux.Lock()
+	defer j.mux.Unlock()
+	return j.decimalTypes[t]
+}
+
+func (j *LogJson) makeDecimalHandlerItem(text func(v reflect.Value) string) *handlerItem {
+	largeAsString := j.getOptions().largeIntAsString
+	return &handlerItem{
+		marshal: func(v reflect.Value, state *EncoderState) {
+			if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+				state.Encoder.WriteToken(jsontext.Null)
+				return
+			}
+			writeNumberText(state.Encoder, text(v), largeAsString)
+		},
+	}
+}
+
+// ratText returns r as a decimal when it has a finite decimal expansion, such
+// as "0.125", or as "num/denom" otherwise.
+func ratText(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	denom := new(big.Int).Set(r.Denom())
+	twos := denom.TrailingZeroBits()
+	denom.Rsh(denom, twos)
+	fives := uint(0)
+	five := big.NewInt(5)
+	var q, m big.Int
+	for {
+		q.QuoRem(denom, five, &m)
+		if m.Sign() != 0 {
+			break
+		}
+		denom.Set(&q)
+		fives++
+	}
+	if denom.Cmp(big.NewInt(1)) != 0 {
+		return r.RatString()
+	}
+	return r.FloatString(int(max(twos, fives)))
+}
+
+// writeNumberText writes the decimal text s as a JSON number, or as a string
+// when it is not a JSON number or, with largeAsString, when float64 may not
+// hold it exactly.
+func writeNumberText(enc *jsontext.Encoder, s string, largeAsString bool) {
+	if !isJSONNumber(s) || largeAsString && !isSafeNumber(s) {
+		enc.WriteToken(jsontext.String(s))
+		return
+	}
+	enc.WriteValue(jsontext.Value(s))
+}
+
+func isJSONNumber(s string) bool {
+	return s != "" && (s[0] == '-' || s[0] >= '0' && s[0] <= '9') && jsontext.Value(s).IsValid()
+}
+
+// isSafeNumber reports whether the JSON number s is an integer within ±2^53 or
+// has at most 15 significant digits, which float64 holds.
+func isSafeNumber(s string) bool {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n <= maxSafeInt && n >= -maxSafeInt
+	}
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return false
+	}
+	mantissa, _, _ := strings.Cut(strings.ToLower(s), "e")
+	digits := strings.Trim(strings.NewReplacer("-", "", ".", "").Replace(mantissa), "0")
+	return len(digits) <= 15
+}
@@ -0,0 +1,59 @@
+package logjson
+
+import (
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCents int64
+
+func (c testCents) LogDecimal() string {
+	return strconv.FormatFloat(float64(c)/100, 'f', 2, 64)
+}
+
+type testDecimal struct {
+	text string
+}
+
+func (d testDecimal) String() string {
+	return d.text
+}
+
+func TestLogJson_BigNumbers(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	type Abc struct {
+		Int     *big.Int
+		Huge    big.Int
+		Float   *big.Float
+		Inf     *big.Float
+		Rat     *big.Rat
+		Third   *big.Rat
+		Nil     *big.Rat
+		Cents   testCents
+		Decimal testDecimal
+	}
+	abc := Abc{
+		Int:     big.NewInt(-42),
+		Huge:    *huge,
+		Float:   big.NewFloat(1.5),
+		Inf:     new(big.Float).SetInf(false),
+		Rat:     big.NewRat(1, 8),
+		Third:   big.NewRat(1, 3),
+		Cents:   1250,
+		Decimal: testDecimal{"0.1000000000000000000001"},
+	}
+	j := NewLogJson()
+	require.Equal(t, `{"Int":-42,"Huge":123456789012345678901234567890,"Float":1.5,"Inf":"+Inf","Rat":0.125,"Third":"1/3","Nil":null,"Cents":12.50,"Decimal":{}}`,
+		string(j.Marshal(abc)))
+	AddDecimalType(j, testDecimal.String)
+	require.Equal(t, `{"Int":-42,"Huge":123456789012345678901234567890,"Float":1.5,"Inf":"+Inf","Rat":0.125,"Third":"1/3","Nil":null,"Cents":12.50,"Decimal":0.1000000000000000000001}`,
+		string(j.Marshal(abc)))
+	j.SetLargeIntAsString(true)
+	require.Equal(t, `{"Int":-42,"Huge":"123456789012345678901234567890","Float":1.5,"Inf":"+Inf","Rat":0.125,"Third":"1/3","Nil":null,"Cents":12.50,"Decimal":"0.1000000000000000000001"}`,
+		string(j.Marshal(abc)))
+	require.Equal(t, `0.1`, string(j.Marshal(testDecimal{"0.1"})))
+	require.Equal(t, `12.5`, string(NewLogJson().Marshal(big.NewRat(25, 2))))
+}
@@ -0,0 +1,138 @@
+package logjson
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+// BytesFormat is how []byte values are written, see SetBytesFormat and
+// LogRuleBytes. In log tags it is "bytes=" followed by the name of the format,
+// such as `log:"bytes=hex"` or `log:"bytes=preview:16:8"`.
+type BytesFormat struct {
+	name string
+	head int
+	tail int
+}
+
+var (
+	// BytesBase64Raw is base64 without padding, the default format.
+	BytesBase64Raw = BytesFormat{name: "base64raw"}
+	// BytesBase64 is base64 with padding.
+	BytesBase64 = BytesFormat{name: "base64"}
+	// BytesBase64URL is the URL safe base64 with padding.
+	BytesBase64URL = BytesFormat{name: "base64url"}
+	BytesHex       = BytesFormat{name: "hex"}
+	// BytesText writes valid and printable UTF-8 as text and other values as
+	// BytesBase64.
+	BytesText = BytesFormat{name: "text"}
+	// BytesMd5 and BytesSha256 write the length and the hash of values, like
+	// LogRuleMd5 and LogRuleSha256.
+	BytesMd5    = BytesFormat{name: "md5"}
+	BytesSha256 = BytesFormat{name: "sha256"}
+)
+
+// BytesPreview writes the hex of the first head and the last tail bytes of
+// values longer than head+tail, such as "0102...ff(len=1024)", shorter values
+// are written as BytesHex.
+func BytesPreview(head, tail int) BytesFormat {
+	return BytesFormat{name: "preview", head: head, tail: tail}
+}
+
+// parseBytesFormat parses the name of a format, such as "hex" or
+// "preview:16:8".
+func parseBytesFormat(s string) (BytesFormat, bool) {
+	if previewStr, ok := strings.CutPrefix(s, "preview:"); ok {
+		headStr, tailStr, _ := strings.Cut(previewStr, ":")
+		head, err1 := strconv.Atoi(headStr)
+		tail, err2 := strconv.Atoi(tailStr)
+		if err1 != nil || err2 != nil || head < 0 || tail < 0 {
+			return BytesFormat{}, false
+		}
+		return BytesPreview(head, tail), true
+	}
+	for _, f := range []BytesFormat{BytesBase64Raw, BytesBase64, BytesBase64URL, BytesHex, BytesText, BytesMd5, BytesSha256} {
+		if f.name == s {
+			return f, true
+		}
+	}
+	return BytesFormat{}, false
+}
+
+// Format returns the string b is written as.
+func (f BytesFormat) Format(b []byte) string {
+	switch f.name {
+	case "base64":
+		return base64.StdEncoding.EncodeToString(b)
+	case "base64url":
+		return base64.URLEncoding.EncodeToString(b)
+	case "hex":
+		return hex.EncodeToString(b)
+	case "text":
+		if isPrintableText(b) {
+			return string(b)
+		}
+		return base64.StdEncoding.EncodeToString(b)
+	case "md5":
+		sum := md5.Sum(b)
+		return fmt.Sprintf("%d;%s", len(b), hex.EncodeToString(sum[:]))
+	case "sha256":
+		return sha256LogString(string(b))
+	case "preview":
+		if len(b) <= f.head+f.tail {
+			return hex.EncodeToString(b)
+		}
+		return fmt.Sprintf("%s...%s(len=%d)", hex.EncodeToString(b[:f.head]), hex.EncodeToString(b[len(b)-f.tail:]), len(b))
+	}
+	return base64.RawStdEncoding.EncodeToString(b)
+}
+
+func isPrintableText(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// SetBytesFormat sets the format of []byte values without rules of their own,
+// the default is BytesBase64Raw.
+func (j *LogJson) SetBytesFormat(format BytesFormat) {
+	j.updateOptions(func(opts *logJsonOptions) {
+		opts.bytesFormat = format
+	})
+}
+
+// LogRuleBytes writes []byte values with format. It is "bytes=format" in log
+// tags, such as "bytes=hex".
+func LogRuleBytes(format BytesFormat) LogRule {
+	return func(conf *logRuleConf) {
+		conf.name = "bytes"
+		conf.bytes = &format
+	}
+}
+
+func isBytesType(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+func createBytesMarshal(t reflect.Type, format BytesFormat) func(v reflect.Value, state *EncoderState) {
+	if !isBytesType(t) {
+		return nil
+	}
+	return func(v reflect.Value, state *EncoderState) {
+		state.Encoder.WriteToken(jsontext.String(format.Format(v.Bytes())))
+	}
+}
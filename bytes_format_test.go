@@ -0,0 +1,42 @@
+package logjson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBytesFormat(t *testing.T) {
+	b := []byte("hi?\xff")
+	require.Equal(t, "aGk//w", BytesBase64Raw.Format(b))
+	require.Equal(t, "aGk//w==", BytesBase64.Format(b))
+	require.Equal(t, "aGk__w==", BytesBase64URL.Format(b))
+	require.Equal(t, "68693fff", BytesHex.Format(b))
+	require.Equal(t, "aGk//w==", BytesText.Format(b))
+	require.Equal(t, "hello\n世界", BytesText.Format([]byte("hello\n世界")))
+	require.Equal(t, "aGVsbG8H", BytesText.Format([]byte("hello\x07")))
+	require.Equal(t, md5LogString("hello"), BytesMd5.Format([]byte("hello")))
+	require.Equal(t, sha256LogString("hello"), BytesSha256.Format([]byte("hello")))
+	require.Equal(t, "0102...ff(len=6)", BytesPreview(2, 1).Format([]byte{1, 2, 3, 4, 5, 0xff}))
+	require.Equal(t, "010203", BytesPreview(2, 1).Format([]byte{1, 2, 3}))
+}
+
+func TestLogJson_BytesFormat(t *testing.T) {
+	type Abc struct {
+		Raw     []byte
+		Hex     []byte `log:"bytes=hex"`
+		Preview []byte `log:"bytes=preview:1:1"`
+		Invalid []byte `log:"bytes=unknown"`
+	}
+	abc := Abc{Raw: []byte("hi"), Hex: []byte("hi"), Preview: []byte("hello"), Invalid: []byte("hi")}
+	require.Equal(t, `{"Raw":"aGk","Hex":"6869","Preview":"68...6f(len=5)","Invalid":"aGk"}`, marshalToLogStr(abc))
+	j := NewLogJson()
+	j.SetBytesFormat(BytesText)
+	require.Equal(t, `{"Raw":"hi","Hex":"6869","Preview":"68...6f(len=5)","Invalid":"hi"}`, string(j.Marshal(abc)))
+	j = NewLogJson()
+	j.SetBytesFormat(BytesText)
+	j.AddLogRule("Raw", LogRuleBytes(BytesSha256))
+	require.Equal(t, `{"Raw":"`+sha256LogString("hi")+`","Hex":"6869","Preview":"68...6f(len=5)","Invalid":"hi"}`, string(j.Marshal(abc)))
+	require.False(t, ValidLogRule("bytes=preview:1"))
+	require.True(t, ValidLogRule("bytes=base64url"))
+}
@@ -0,0 +1,25 @@
+module github.com/ethanvc/logjson/cmd
+
+go 1.22.0
+
+require (
+	github.com/ethanvc/logjson v0.0.0-00010101000000-000000000000
+	github.com/ethanvc/logjson/analysis v0.0.0-00010101000000-000000000000
+	github.com/go-json-experiment/json v0.0.0-20240418180308-af2d5061e6c2
+	github.com/stretchr/testify v1.9.0
+	golang.org/x/tools v0.26.0
+	google.golang.org/protobuf v1.34.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/mod v0.21.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace (
+	github.com/ethanvc/logjson => ../
+	github.com/ethanvc/logjson/analysis => ../analysis
+)
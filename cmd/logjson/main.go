@@ -0,0 +1,137 @@
+// Command logjson provides offline tools for logs written by logjson.
+//
+// Usage:
+//
+//	logjson detokenize -key-file key.hex [token ...]
+//
+// detokenize prints the values of the tokens written by
+// logjson.LogRuleTokenize. Without token arguments it reads log lines from
+// stdin and replaces every token in them. The key is hex encoded, it is read
+// from -key-file or the LOGJSON_TOKENIZER_KEY environment variable.
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/ethanvc/logjson"
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "detokenize":
+		err = detokenize(os.Args[2:], os.Stdin, os.Stdout)
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: logjson detokenize -key-file key.hex [token ...]")
+	os.Exit(2)
+}
+
+var tokenRegexp = regexp.MustCompile(regexp.QuoteMeta(logjson.TokenPrefix) + `[A-Za-z0-9_-]+`)
+
+func detokenize(args []string, in io.Reader, out io.Writer) error {
+	flags := flag.NewFlagSet("detokenize", flag.ExitOnError)
+	keyFile := flags.String("key-file", "", "file containing the hex encoded tokenizer key")
+	flags.Parse(args)
+	tokenizer, err := newTokenizer(*keyFile)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		for _, token := range flags.Args() {
+			s, err := tokenizer.Detokenize(token)
+			if err != nil {
+				return fmt.Errorf("%s: %w", token, err)
+			}
+			fmt.Fprintln(out, s)
+		}
+		return nil
+	}
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		fmt.Fprintln(out, detokenizeLine(tokenizer, scanner.Text()))
+	}
+	return scanner.Err()
+}
+
+// detokenizeLine replaces the tokens in line with their values, the values of
+// tokens inside JSON strings are escaped so the line stays valid JSON. Tokens
+// which cannot be detokenized are kept.
+func detokenizeLine(tokenizer *logjson.Tokenizer, line string) string {
+	var b strings.Builder
+	inString := false
+	prev := 0
+	for _, loc := range tokenRegexp.FindAllStringIndex(line, -1) {
+		inString = scanJSONString(line[prev:loc[0]], inString)
+		b.WriteString(line[prev:loc[0]])
+		prev = loc[1]
+		token := line[loc[0]:loc[1]]
+		s, err := tokenizer.Detokenize(token)
+		if err != nil {
+			b.WriteString(token)
+			continue
+		}
+		if inString {
+			quoted, _ := jsontext.AppendQuote(nil, s)
+			s = string(quoted[1 : len(quoted)-1])
+		}
+		b.WriteString(s)
+	}
+	b.WriteString(line[prev:])
+	return b.String()
+}
+
+// scanJSONString reports whether a JSON text is inside a string after s, when
+// it is inside a string before s according to inString. Tokens contain no
+// quotes or backslashes, so they do not change the state.
+func scanJSONString(s string, inString bool) bool {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case inString && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			inString = !inString
+		}
+	}
+	return inString
+}
+
+func newTokenizer(keyFile string) (*logjson.Tokenizer, error) {
+	keyHex := os.Getenv("LOGJSON_TOKENIZER_KEY")
+	if keyFile != "" {
+		content, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		keyHex = string(content)
+	}
+	if keyHex == "" {
+		return nil, errors.New("no tokenizer key, use -key-file or LOGJSON_TOKENIZER_KEY")
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(keyHex))
+	if err != nil {
+		return nil, err
+	}
+	return logjson.NewTokenizer(key)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/ethanvc/logjson"
+	"github.com/go-json-experiment/json/jsontext"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetokenize(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	t.Setenv("LOGJSON_TOKENIZER_KEY", hex.EncodeToString(key))
+	tokenizer, err := logjson.NewTokenizer(key)
+	require.NoError(t, err)
+	quoted := tokenizer.Tokenize(`say "hi" \ bye`)
+	plain := tokenizer.Tokenize("abc")
+
+	out := bytes.NewBuffer(nil)
+	require.NoError(t, detokenize([]string{quoted, plain}, nil, out))
+	require.Equal(t, "say \"hi\" \\ bye\nabc\n", out.String())
+
+	in := strings.NewReader(`{"a":"` + quoted + `","b":"x ` + plain + `","c":"\"` + plain + `"}` + "\n" +
+		plain + " " + invalidToken + "\n")
+	out.Reset()
+	require.NoError(t, detokenize(nil, in, out))
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	require.Equal(t, `{"a":"say \"hi\" \\ bye","b":"x abc","c":"\"abc"}`, lines[0])
+	require.True(t, jsontext.Value(lines[0]).IsValid())
+	require.Equal(t, "abc "+invalidToken, lines[1])
+}
+
+const invalidToken = logjson.TokenPrefix + "invalid"
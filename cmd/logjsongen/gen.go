@@ -0,0 +1,410 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"reflect"
+	"strings"
+
+	"github.com/ethanvc/logjson"
+	"golang.org/x/tools/go/packages"
+)
+
+// generate returns the source of the MarshalLogJSONState methods of typeNames
+// in the package in dir.
+func generate(dir string, typeNames []string) ([]byte, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedSyntax | packages.NeedImports | packages.NeedDeps,
+		Dir:  dir,
+	}
+	pkgs, err := packages.Load(cfg, ".")
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("%d packages found in %s", len(pkgs), dir)
+	}
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		return nil, pkg.Errors[0]
+	}
+	g := &generator{
+		pkg: pkg.Types,
+	}
+	var named []*types.Named
+	for _, name := range typeNames {
+		obj, ok := pkg.Types.Scope().Lookup(name).(*types.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("type %s not found", name)
+		}
+		t, ok := obj.Type().(*types.Named)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a named type", name)
+		}
+		if _, ok := t.Underlying().(*types.Struct); !ok {
+			return nil, fmt.Errorf("%s is not a struct", name)
+		}
+		if types.NewMethodSet(types.NewPointer(t)).Lookup(nil, "LogJSONRules") != nil {
+			return nil, fmt.Errorf("%s declares rules by LogJSONRules, which are not supported", name)
+		}
+		named = append(named, t)
+	}
+	for _, t := range named {
+		g.generateType(t)
+	}
+	return g.source()
+}
+
+type generator struct {
+	pkg      *types.Package
+	body     bytes.Buffer
+	ruleVars []string
+}
+
+func (g *generator) p(format string, args ...any) {
+	fmt.Fprintf(&g.body, format, args...)
+	g.body.WriteByte('\n')
+}
+
+func (g *generator) source() ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "// Code generated by logjsongen. DO NOT EDIT.")
+	fmt.Fprintln(&buf)
+	fmt.Fprintf(&buf, "package %s\n\n", g.pkg.Name())
+	fmt.Fprintln(&buf, "import (")
+	fmt.Fprintln(&buf, `"github.com/ethanvc/logjson"`)
+	fmt.Fprintln(&buf, `"github.com/go-json-experiment/json/jsontext"`)
+	fmt.Fprintln(&buf, ")")
+	fmt.Fprintln(&buf)
+	buf.Write(g.body.Bytes())
+	if len(g.ruleVars) > 0 {
+		fmt.Fprintln(&buf, "var (")
+		for _, v := range g.ruleVars {
+			fmt.Fprintln(&buf, v)
+		}
+		fmt.Fprintln(&buf, ")")
+	}
+	return format.Source(buf.Bytes())
+}
+
+func (g *generator) generateType(t *types.Named) {
+	name := t.Obj().Name()
+	g.p("func (x *%s) MarshalLogJSONState(state *logjson.EncoderState) {", name)
+	g.p("if x == nil {")
+	g.p("state.WriteToken(jsontext.Null)")
+	g.p("return")
+	g.p("}")
+	g.p("if !state.UseGeneratedCode() {")
+	g.p("state.MarshalReflect(x)")
+	g.p("return")
+	g.p("}")
+	g.p("enc := state.Encoder")
+	g.p("enc.WriteToken(jsontext.ObjectStart)")
+	for _, f := range visibleFields(t.Underlying().(*types.Struct)) {
+		g.generateField(name, f)
+	}
+	g.p("enc.WriteToken(jsontext.ObjectEnd)")
+	g.p("}")
+	g.p("")
+}
+
+type visibleField struct {
+	v     *types.Var
+	tag   string
+	index []int
+	// path is the chain of embedded fields containing v.
+	path []*types.Var
+}
+
+// visibleFields returns the fields of st like reflect.VisibleFields.
+func visibleFields(st *types.Struct) []visibleField {
+	w := &visibleFieldsWalker{
+		byName:   make(map[string]int),
+		visiting: make(map[*types.Struct]bool),
+	}
+	w.walk(st, nil, nil)
+	var result []visibleField
+	for _, f := range w.fields {
+		if f.v != nil {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+type visibleFieldsWalker struct {
+	byName   map[string]int
+	visiting map[*types.Struct]bool
+	fields   []visibleField
+}
+
+func (w *visibleFieldsWalker) walk(st *types.Struct, index []int, path []*types.Var) {
+	if w.visiting[st] {
+		return
+	}
+	w.visiting[st] = true
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		fieldIndex := append(append([]int(nil), index...), i)
+		add := true
+		if oldIndex, ok := w.byName[v.Name()]; ok {
+			old := &w.fields[oldIndex]
+			if len(fieldIndex) == len(old.index) {
+				old.v = nil
+				add = false
+			} else if len(fieldIndex) < len(old.index) {
+				old.v = nil
+			} else {
+				add = false
+			}
+		}
+		if add {
+			w.byName[v.Name()] = len(w.fields)
+			w.fields = append(w.fields, visibleField{v: v, tag: st.Tag(i), index: fieldIndex, path: path})
+		}
+		if v.Anonymous() {
+			t := v.Type()
+			if ptr, ok := t.Underlying().(*types.Pointer); ok {
+				t = ptr.Elem()
+			}
+			if embedded, ok := t.Underlying().(*types.Struct); ok {
+				w.walk(embedded, fieldIndex, append(append([]*types.Var(nil), path...), v))
+			}
+		}
+	}
+	delete(w.visiting, st)
+}
+
+func (g *generator) generateField(typeName string, f visibleField) {
+	if f.v.Anonymous() || !f.v.Exported() {
+		return
+	}
+	tag := reflect.StructTag(f.tag)
+	name, omitempty := parseJsonTag(f.v.Name(), tag.Get("json"))
+	rule := tag.Get("log")
+	if !logjson.ValidLogRule(rule) {
+		rule = ""
+	}
+	if rule == "omit" {
+		return
+	}
+	expr := "x"
+	closes := 0
+	for _, embedded := range f.path {
+		expr += "." + embedded.Name()
+		if _, ok := embedded.Type().Underlying().(*types.Pointer); ok {
+			g.p("if %s != nil {", expr)
+			closes++
+		}
+	}
+	expr += "." + f.v.Name()
+	if omitempty {
+		if cond := nonEmptyExpr(f.v.Type(), expr); cond != "" {
+			g.p("if %s {", cond)
+			closes++
+		}
+	}
+	g.p("enc.WriteToken(jsontext.String(%q))", name)
+	ruleVar := fmt.Sprintf("logjsonRule_%s_%s", typeName, f.v.Name())
+	ruleDecl := fmt.Sprintf("%s = logjson.NewStringRule(%q)", ruleVar, rule)
+	switch {
+	case rule == "":
+		g.generateValue(f.v.Type(), expr)
+	case rule == "json" && (isBytes(f.v.Type()) || isString(f.v.Type())):
+		g.ruleVars = append(g.ruleVars, ruleDecl)
+		g.p("%s.WriteInlineJSON(state, %s)", ruleVar, convert(f.v.Type(), types.String, expr))
+	case strings.HasPrefix(rule, "enum=") && isEnumOrEnumPointer(f.v.Type()):
+		g.ruleVars = append(g.ruleVars, ruleDecl)
+		g.p("%s.WriteValue(state, %s)", ruleVar, expr)
+	case strings.HasPrefix(rule, "bytes=") && isBytes(f.v.Type()):
+		g.ruleVars = append(g.ruleVars, ruleDecl)
+		g.p("enc.WriteToken(jsontext.String(%s.ApplyBytes(%s)))", ruleVar, expr)
+	case !strings.HasPrefix(rule, "bytes=") && !strings.HasPrefix(rule, "enum=") && rule != "json" && isStringOrStringPointer(f.v.Type()):
+		g.ruleVars = append(g.ruleVars, ruleDecl)
+		g.generateStringRule(f.v.Type(), expr, ruleVar)
+	default:
+		g.generateValue(f.v.Type(), expr)
+	}
+	for i := 0; i < closes; i++ {
+		g.p("}")
+	}
+}
+
+func parseJsonTag(fieldName string, tag string) (string, bool) {
+	name := fieldName
+	omitempty := false
+	for i, part := range strings.Split(tag, ",") {
+		if i == 0 {
+			if part != "" {
+				name = part
+			}
+			continue
+		}
+		if part == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty
+}
+
+// nonEmptyExpr returns the condition of a field not being empty like the
+// omitempty of encoding/json v1, an empty result means it is never empty.
+func nonEmptyExpr(t types.Type, expr string) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return expr
+		case u.Info()&types.IsString != 0:
+			return "len(" + expr + ") != 0"
+		case u.Info()&types.IsNumeric != 0 && u.Info()&types.IsComplex == 0:
+			return expr + " != 0"
+		}
+	case *types.Slice, *types.Map, *types.Array:
+		return "len(" + expr + ") != 0"
+	case *types.Pointer, *types.Interface:
+		return expr + " != nil"
+	}
+	return ""
+}
+
+func isBytes(t types.Type) bool {
+	s, ok := t.Underlying().(*types.Slice)
+	if !ok {
+		return false
+	}
+	b, ok := s.Elem().Underlying().(*types.Basic)
+	return ok && (b.Kind() == types.Uint8)
+}
+
+func isString(t types.Type) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsString != 0
+}
+
+func isStringOrStringPointer(t types.Type) bool {
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsString != 0
+}
+
+func (g *generator) generateStringRule(t types.Type, expr string, ruleVar string) {
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		g.p("if %s == nil {", expr)
+		g.p("enc.WriteToken(jsontext.Null)")
+		g.p("} else {")
+		g.p("enc.WriteToken(jsontext.String(%s.Apply(%s)))", ruleVar, convert(ptr.Elem(), types.String, "*"+expr))
+		g.p("}")
+		return
+	}
+	g.p("enc.WriteToken(jsontext.String(%s.Apply(%s)))", ruleVar, convert(t, types.String, expr))
+}
+
+// convert returns expr converted to the basic type kind, the conversion is
+// left out when expr already has the type.
+func convert(t types.Type, kind types.BasicKind, expr string) string {
+	if types.Identical(t, types.Typ[kind]) {
+		return expr
+	}
+	return types.Typ[kind].Name() + "(" + expr + ")"
+}
+
+// generateValue writes the code marshaling expr of type t, the values of other
+// generated types are written by the LogJson of the state, so its rules and
+// cycle detection apply to them.
+func (g *generator) generateValue(t types.Type, expr string) {
+	if hasMarshalMethod(t) || isEnum(t) {
+		g.generateFallback(expr)
+		return
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		info := u.Info()
+		switch {
+		case info&types.IsBoolean != 0:
+			g.p("enc.WriteToken(jsontext.Bool(%s))", convert(t, types.Bool, expr))
+		case info&types.IsString != 0:
+			g.p("enc.WriteToken(jsontext.String(%s))", convert(t, types.String, expr))
+		case info&types.IsInteger != 0 && info&types.IsUnsigned == 0:
+			g.p("state.WriteInt(%s)", convert(t, types.Int64, expr))
+		case info&types.IsInteger != 0 && u.Kind() != types.Uintptr:
+			g.p("state.WriteUint(%s)", convert(t, types.Uint64, expr))
+		case info&types.IsFloat != 0:
+			bits := 64
+			if u.Kind() == types.Float32 {
+				bits = 32
+			}
+			g.p("state.WriteFloat(%s, %d)", convert(t, types.Float64, expr), bits)
+		default:
+			g.generateFallback(expr)
+		}
+	case *types.Pointer:
+		if !g.isSpecialized(u.Elem()) {
+			g.generateFallback(expr)
+			return
+		}
+		g.p("if %s == nil {", expr)
+		g.p("enc.WriteToken(jsontext.Null)")
+		g.p("} else {")
+		g.generateValue(u.Elem(), "*"+expr)
+		g.p("}")
+	default:
+		g.generateFallback(expr)
+	}
+}
+
+// isSpecialized reports whether generateValue writes t without the fallback.
+func (g *generator) isSpecialized(t types.Type) bool {
+	if hasMarshalMethod(t) || isEnum(t) {
+		return false
+	}
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return false
+	}
+	return b.Info()&(types.IsBoolean|types.IsString|types.IsInteger|types.IsFloat) != 0 && b.Kind() != types.Uintptr
+}
+
+func (g *generator) generateFallback(expr string) {
+	g.p("state.MarshalValue(%s)", expr)
+}
+
+// hasMarshalMethod reports whether t or *t has a method logjson prefers to
+// its kind, such as MarshalLogJSON or Error.
+func hasMarshalMethod(t types.Type) bool {
+	if _, ok := t.Underlying().(*types.Pointer); !ok && !types.IsInterface(t) {
+		t = types.NewPointer(t)
+	}
+	mset := types.NewMethodSet(t)
+	for _, name := range []string{"MarshalLogJSONState", "MarshalLogJSON", "MarshalJSONV2", "Error", "ProtoReflect", "LogDecimal"} {
+		if mset.Lookup(nil, name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isEnum reports whether t is a named bool, number or string type with a
+// String method, which logjson writes by LogJson.SetEnumFormat.
+func isEnum(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok || hasMarshalMethod(t) {
+		return false
+	}
+	b, ok := named.Underlying().(*types.Basic)
+	if !ok || b.Info()&(types.IsBoolean|types.IsString|types.IsInteger|types.IsFloat) == 0 || b.Kind() == types.Uintptr {
+		return false
+	}
+	return types.NewMethodSet(types.NewPointer(t)).Lookup(nil, "String") != nil
+}
+
+func isEnumOrEnumPointer(t types.Type) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	return isEnum(t)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratedCode(t *testing.T) {
+	content, err := generate("../../internal/logjsongentest", []string{"Order", "Item", "Buyer"})
+	require.NoError(t, err)
+	expected, err := os.ReadFile("../../internal/logjsongentest/logjson_gen.go")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(content))
+}
+
+func TestGenerateNotStruct(t *testing.T) {
+	_, err := generate("../../internal/logjsongentest", []string{"Status"})
+	require.Error(t, err)
+	_, err = generate("../../internal/logjsongentest", []string{"NotExist"})
+	require.Error(t, err)
+}
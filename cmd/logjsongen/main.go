@@ -0,0 +1,48 @@
+// Command logjsongen generates MarshalLogJSONState methods for Go structs, so
+// logjson marshals them without reflection.
+//
+// Usage:
+//
+//	//go:generate logjsongen -type=Order,Item
+//
+// The generated methods write the same output as LogJson.Marshal: fields are
+// named by json tags, omitempty is respected and log tags are applied
+// statically. When the LogJson has rules of its own, such as the ones added
+// with LogJson.AddLogRule or LogJson.AddTypeRule, or options the methods do
+// not apply, such as SetInlineJSON, or the call has options such as
+// WithoutLogRules, the methods fall back to reflection, see
+// EncoderState.UseGeneratedCode. Types with LogJSONRules methods are not
+// supported. Fields which can not be specialized, such as slices, maps and
+// types with their own marshalers, are marshaled with the LogJson of the call.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma separated list of struct type names")
+	output := flag.String("output", "logjson_gen.go", "output file name")
+	flag.Parse()
+	if *typeNames == "" {
+		fmt.Fprintln(os.Stderr, "usage: logjsongen -type=T1,T2 [-output file] [dir]")
+		os.Exit(2)
+	}
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	src, err := generate(dir, strings.Split(*typeNames, ","))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "logjsongen:", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(filepath.Join(dir, *output), src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "logjsongen:", err)
+		os.Exit(1)
+	}
+}
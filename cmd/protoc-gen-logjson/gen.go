@@ -0,0 +1,414 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethanvc/logjson"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	jsontextPackage = protogen.GoImportPath("github.com/go-json-experiment/json/jsontext")
+	logjsonPackage  = protogen.GoImportPath("github.com/ethanvc/logjson")
+	protoPackage    = protogen.GoImportPath("google.golang.org/protobuf/proto")
+	base64Package   = protogen.GoImportPath("encoding/base64")
+	mathPackage     = protogen.GoImportPath("math")
+	sortPackage     = protogen.GoImportPath("sort")
+	strconvPackage  = protogen.GoImportPath("strconv")
+)
+
+type generator struct {
+	g         *protogen.GeneratedFile
+	jsonNames bool
+	tmpCount  int
+}
+
+func generateFile(gen *protogen.Plugin, file *protogen.File, jsonNames bool) *protogen.GeneratedFile {
+	if len(file.Messages) == 0 {
+		return nil
+	}
+	filename := file.GeneratedFilenamePrefix + "_logjson.pb.go"
+	g := gen.NewGeneratedFile(filename, file.GoImportPath)
+	g.P("// Code generated by protoc-gen-logjson. DO NOT EDIT.")
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+	gr := &generator{g: g, jsonNames: jsonNames}
+	for _, msg := range file.Messages {
+		gr.generateMessage(msg)
+	}
+	return g
+}
+
+func (gr *generator) generateMessage(msg *protogen.Message) {
+	for _, nested := range msg.Messages {
+		gr.generateMessage(nested)
+	}
+	if msg.Desc.IsMapEntry() {
+		return
+	}
+	g := gr.g
+	msgRule := messageRule(msg.Desc)
+	var ruleVars []string
+	g.P("func (x *", msg.GoIdent, ") MarshalLogJSONState(state *", logjsonPackage.Ident("EncoderState"), ") {")
+	g.P("if x == nil {")
+	g.P("state.WriteToken(", jsontextPackage.Ident("Null"), ")")
+	g.P("return")
+	g.P("}")
+	g.P("if !state.UseGeneratedProtoCode(", gr.jsonNames, ") {")
+	g.P("state.MarshalReflect(x)")
+	g.P("return")
+	g.P("}")
+	switch {
+	case msgRule == "omit":
+		g.P("state.WriteToken(", jsontextPackage.Ident("Null"), ")")
+		g.P("}")
+		g.P()
+		return
+	case msgRule == "md5" || msgRule == "sha256":
+		ruleVar := ruleVarName(msg, nil)
+		ruleVars = append(ruleVars, fmt.Sprintf("%s = %s(%q)", ruleVar, g.QualifiedGoIdent(logjsonPackage.Ident("NewStringRule")), msgRule))
+		g.P("buf, _ := ", protoPackage.Ident("MarshalOptions"), "{Deterministic: true, AllowPartial: true}.Marshal(x)")
+		g.P("state.WriteToken(", jsontextPackage.Ident("String"), "(", ruleVar, ".Apply(string(buf))))")
+		g.P("}")
+		g.P()
+		gr.generateRuleVars(ruleVars)
+		return
+	}
+	g.P("enc := state.Encoder")
+	g.P("enc.WriteToken(", jsontextPackage.Ident("ObjectStart"), ")")
+	for _, field := range msg.Fields {
+		rule := fieldRule(field.Desc)
+		if rule == "omit" {
+			continue
+		}
+		if field.Message != nil && !field.Desc.IsList() && !field.Desc.IsMap() && messageRule(field.Message.Desc) == "omit" {
+			continue
+		}
+		ruleVar := ""
+		if ruleApplies(rule, field.Desc) {
+			ruleVar = ruleVarName(msg, field)
+			ruleVars = append(ruleVars, fmt.Sprintf("%s = %s(%q)", ruleVar, g.QualifiedGoIdent(logjsonPackage.Ident("NewStringRule")), rule))
+		}
+		gr.generateField(field, ruleVar)
+	}
+	g.P("enc.WriteToken(", jsontextPackage.Ident("ObjectEnd"), ")")
+	g.P("}")
+	g.P()
+	gr.generateRuleVars(ruleVars)
+}
+
+func (gr *generator) generateRuleVars(ruleVars []string) {
+	if len(ruleVars) == 0 {
+		return
+	}
+	gr.g.P("var (")
+	for _, v := range ruleVars {
+		gr.g.P(v)
+	}
+	gr.g.P(")")
+	gr.g.P()
+}
+
+func ruleVarName(msg *protogen.Message, field *protogen.Field) string {
+	if field == nil {
+		return "logjsonRule_" + msg.GoIdent.GoName
+	}
+	return "logjsonRule_" + msg.GoIdent.GoName + "_" + field.GoName
+}
+
+func (gr *generator) fieldName(field *protogen.Field) string {
+	if gr.jsonNames {
+		return field.Desc.JSONName()
+	}
+	return string(field.Desc.Name())
+}
+
+func (gr *generator) generateField(field *protogen.Field, ruleVar string) {
+	g := gr.g
+	fd := field.Desc
+	writeName := func() {
+		g.P("enc.WriteToken(", jsontextPackage.Ident("String"), "(", fmt.Sprintf("%q", gr.fieldName(field)), "))")
+	}
+	expr := "x." + field.GoName
+	switch {
+	case field.Oneof != nil && !field.Oneof.Desc.IsSynthetic():
+		g.P("if ov, ok := x.", field.Oneof.GoName, ".(*", field.GoIdent, "); ok", gr.visibleEnumCond(field, "ov."+field.GoName), " {")
+		writeName()
+		gr.generateSingular(field, "ov."+field.GoName, ruleVar)
+		g.P("}")
+	case fd.IsList():
+		g.P("if len(", expr, ") > 0 {")
+		writeName()
+		g.P("enc.WriteToken(", jsontextPackage.Ident("ArrayStart"), ")")
+		g.P("for _, v := range ", expr, " {")
+		if cond := gr.hiddenEnumCond(field, "v"); cond != "" {
+			g.P("if ", cond, " {")
+			g.P("continue")
+			g.P("}")
+		}
+		gr.generateSingular(field, "v", "")
+		g.P("}")
+		g.P("enc.WriteToken(", jsontextPackage.Ident("ArrayEnd"), ")")
+		g.P("}")
+	case fd.IsMap():
+		g.P("if len(", expr, ") > 0 {")
+		writeName()
+		gr.generateMap(field, expr)
+		g.P("}")
+	case fd.Message() != nil:
+		g.P("if ", expr, " != nil {")
+		writeName()
+		gr.generateSingular(field, expr, ruleVar)
+		g.P("}")
+	case fd.HasPresence():
+		g.P("if ", expr, " != nil", gr.visibleEnumCond(field, "*"+expr), " {")
+		writeName()
+		gr.generateSingular(field, "*"+expr, ruleVar)
+		g.P("}")
+	default:
+		g.P("if ", gr.nonZeroExpr(field, expr), gr.visibleEnumCond(field, expr), " {")
+		writeName()
+		gr.generateSingular(field, expr, ruleVar)
+		g.P("}")
+	}
+}
+
+// nonZeroExpr returns the condition of protoreflect.Message.Has for fields
+// without presence.
+func (gr *generator) nonZeroExpr(field *protogen.Field, expr string) string {
+	switch field.Desc.Kind() {
+	case protoreflect.BoolKind:
+		return expr
+	case protoreflect.StringKind:
+		return expr + ` != ""`
+	case protoreflect.BytesKind:
+		return "len(" + expr + ") > 0"
+	case protoreflect.FloatKind:
+		return gr.g.QualifiedGoIdent(mathPackage.Ident("Float32bits")) + "(" + expr + ") != 0"
+	case protoreflect.DoubleKind:
+		return gr.g.QualifiedGoIdent(mathPackage.Ident("Float64bits")) + "(" + expr + ") != 0"
+	}
+	return expr + " != 0"
+}
+
+func (gr *generator) generateMap(field *protogen.Field, expr string) {
+	g := gr.g
+	keyField := field.Message.Fields[0]
+	valueField := field.Message.Fields[1]
+	keyType := scalarGoType(keyField.Desc.Kind())
+	gr.tmpCount++
+	keys := fmt.Sprintf("keys%d", gr.tmpCount)
+	g.P(keys, " := make([]", keyType, ", 0, len(", expr, "))")
+	g.P("for k := range ", expr, " {")
+	g.P(keys, " = append(", keys, ", k)")
+	g.P("}")
+	if keyField.Desc.Kind() == protoreflect.BoolKind {
+		g.P(sortPackage.Ident("Slice"), "(", keys, ", func(a, b int) bool { return !", keys, "[a] && ", keys, "[b] })")
+	} else {
+		g.P(sortPackage.Ident("Slice"), "(", keys, ", func(a, b int) bool { return ", keys, "[a] < ", keys, "[b] })")
+	}
+	g.P("enc.WriteToken(", jsontextPackage.Ident("ObjectStart"), ")")
+	g.P("for _, k := range ", keys, " {")
+	if cond := gr.hiddenEnumCond(valueField, expr+"[k]"); cond != "" {
+		g.P("if ", cond, " {")
+		g.P("continue")
+		g.P("}")
+	}
+	switch keyField.Desc.Kind() {
+	case protoreflect.StringKind:
+		g.P("enc.WriteToken(", jsontextPackage.Ident("String"), "(k))")
+	case protoreflect.BoolKind:
+		g.P("enc.WriteToken(", jsontextPackage.Ident("String"), "(", strconvPackage.Ident("FormatBool"), "(k)))")
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		g.P("enc.WriteToken(", jsontextPackage.Ident("String"), "(", strconvPackage.Ident("FormatUint"), "(uint64(k), 10)))")
+	default:
+		g.P("enc.WriteToken(", jsontextPackage.Ident("String"), "(", strconvPackage.Ident("FormatInt"), "(int64(k), 10)))")
+	}
+	gr.generateSingular(valueField, expr+"[k]", "")
+	g.P("}")
+	g.P("enc.WriteToken(", jsontextPackage.Ident("ObjectEnd"), ")")
+}
+
+func scalarGoType(kind protoreflect.Kind) string {
+	switch kind {
+	case protoreflect.BoolKind:
+		return "bool"
+	case protoreflect.StringKind:
+		return "string"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return "int32"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return "uint32"
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return "int64"
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return "uint64"
+	}
+	panic("unexpected map key kind " + kind.String())
+}
+
+// generateSingular writes the code writing the value expr of field, ruleVar
+// is the StringRule applied to string values.
+func (gr *generator) generateSingular(field *protogen.Field, expr string, ruleVar string) {
+	g := gr.g
+	switch field.Desc.Kind() {
+	case protoreflect.BoolKind:
+		g.P("enc.WriteToken(", jsontextPackage.Ident("Bool"), "(", expr, "))")
+	case protoreflect.StringKind:
+		if ruleVar != "" && fieldRule(field.Desc) == "json" {
+			g.P(ruleVar, ".WriteInlineJSON(state, ", expr, ")")
+			return
+		}
+		if ruleVar != "" {
+			expr = ruleVar + ".Apply(" + expr + ")"
+		}
+		g.P("enc.WriteToken(", jsontextPackage.Ident("String"), "(", expr, "))")
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		g.P("enc.WriteToken(", jsontextPackage.Ident("Int"), "(int64(", expr, ")))")
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		g.P("enc.WriteToken(", jsontextPackage.Ident("Uint"), "(uint64(", expr, ")))")
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		g.P("enc.WriteToken(", jsontextPackage.Ident("String"), "(", strconvPackage.Ident("FormatInt"), "(", expr, ", 10)))")
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		g.P("enc.WriteToken(", jsontextPackage.Ident("String"), "(", strconvPackage.Ident("FormatUint"), "(", expr, ", 10)))")
+	case protoreflect.FloatKind:
+		g.P(logjsonPackage.Ident("WriteFloat"), "(enc, float64(", expr, "), 32)")
+	case protoreflect.DoubleKind:
+		g.P(logjsonPackage.Ident("WriteFloat"), "(enc, ", expr, ", 64)")
+	case protoreflect.BytesKind:
+		if ruleVar != "" && fieldRule(field.Desc) == "json" {
+			g.P(ruleVar, ".WriteInlineJSON(state, string(", expr, "))")
+			return
+		}
+		if ruleVar != "" {
+			g.P("enc.WriteToken(", jsontextPackage.Ident("String"), "(", ruleVar, ".ApplyBytes(", expr, ")))")
+			return
+		}
+		g.P("enc.WriteToken(", jsontextPackage.Ident("String"), "(", base64Package.Ident("StdEncoding"), ".EncodeToString(", expr, ")))")
+	case protoreflect.EnumKind:
+		gr.generateEnum(field.Enum, expr)
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		g.P("state.MarshalValue(", expr, ")")
+	default:
+		g.P("enc.WriteToken(", jsontextPackage.Ident("Null"), ")")
+	}
+}
+
+func (gr *generator) generateEnum(enum *protogen.Enum, expr string) {
+	g := gr.g
+	if enum.Desc.FullName() == "google.protobuf.NullValue" {
+		g.P("enc.WriteToken(", jsontextPackage.Ident("Null"), ")")
+		return
+	}
+	g.P("switch ", expr, " {")
+	seen := make(map[protoreflect.EnumNumber]bool)
+	for _, value := range enum.Values {
+		if seen[value.Desc.Number()] {
+			continue
+		}
+		seen[value.Desc.Number()] = true
+		if hide, _ := proto.GetExtension(value.Desc.Options(), logjson.E_LogJsonHide).(bool); hide {
+			// the callers omit hidden values, see hiddenEnumCond.
+			continue
+		}
+		g.P("case ", value.GoIdent, ":")
+		g.P("enc.WriteToken(", jsontextPackage.Ident("String"), "(", fmt.Sprintf("%q", value.Desc.Name()), "))")
+	}
+	g.P("default:")
+	g.P("enc.WriteToken(", jsontextPackage.Ident("Int"), "(int64(", expr, ")))")
+	g.P("}")
+}
+
+// hiddenEnumCond returns the condition of expr holding a value of field with
+// the log_json_hide option, or "" when field has no such value. The fields,
+// list elements and map entries holding them are omitted.
+func (gr *generator) hiddenEnumCond(field *protogen.Field, expr string) string {
+	return strings.Join(gr.hiddenEnumConds(field, expr, " == "), " || ")
+}
+
+// visibleEnumCond returns the condition appended to the presence condition of
+// field, it is false for the values hiddenEnumCond reports.
+func (gr *generator) visibleEnumCond(field *protogen.Field, expr string) string {
+	var b strings.Builder
+	for _, cond := range gr.hiddenEnumConds(field, expr, " != ") {
+		b.WriteString(" && " + cond)
+	}
+	return b.String()
+}
+
+func (gr *generator) hiddenEnumConds(field *protogen.Field, expr, op string) []string {
+	if field.Enum == nil {
+		return nil
+	}
+	var conds []string
+	for _, value := range field.Enum.Values {
+		if hide, _ := proto.GetExtension(value.Desc.Options(), logjson.E_LogJsonHide).(bool); hide {
+			conds = append(conds, expr+op+gr.g.QualifiedGoIdent(value.GoIdent))
+		}
+	}
+	return conds
+}
+
+// ruleApplies reports whether rule changes the output of the values of fd,
+// bytes rules apply to singular bytes fields and other rules to singular
+// string fields.
+func ruleApplies(rule string, fd protoreflect.FieldDescriptor) bool {
+	if rule == "" || fd.IsList() || fd.IsMap() || strings.HasPrefix(rule, "enum=") {
+		return false
+	}
+	if strings.HasPrefix(rule, "bytes=") {
+		return fd.Kind() == protoreflect.BytesKind
+	}
+	if rule == "json" {
+		return fd.Kind() == protoreflect.StringKind || fd.Kind() == protoreflect.BytesKind
+	}
+	return fd.Kind() == protoreflect.StringKind
+}
+
+// fieldRule returns the rule of fd from proto options in the order logjson
+// resolves them: log_json_rule, log_json and then log_json_default of the
+// message. Invalid rules and log_json_rule options without action are ignored.
+func fieldRule(fd protoreflect.FieldDescriptor) string {
+	opts := fd.Options()
+	if proto.HasExtension(opts, logjson.E_LogJsonRule) {
+		rule, _ := proto.GetExtension(opts, logjson.E_LogJsonRule).(*logjson.LogJsonRule)
+		if s := structuredRule(rule); s != "" {
+			return s
+		}
+	}
+	if s, _ := proto.GetExtension(opts, logjson.E_LogJson).(string); logjson.ValidLogRule(s) {
+		return s
+	}
+	if s, _ := proto.GetExtension(fd.ContainingMessage().Options(), logjson.E_LogJsonDefault).(string); logjson.ValidLogRule(s) {
+		return s
+	}
+	return ""
+}
+
+func structuredRule(rule *logjson.LogJsonRule) string {
+	switch rule.GetAction() {
+	case logjson.LogJsonRule_ACTION_OMIT:
+		return "omit"
+	case logjson.LogJsonRule_ACTION_HASH:
+		if rule.GetHashAlgorithm() == logjson.LogJsonRule_HASH_ALGORITHM_SHA256 {
+			return "sha256"
+		}
+		return "md5"
+	case logjson.LogJsonRule_ACTION_MASK:
+		return fmt.Sprintf("mask=%d:%d", max(rule.GetMaskPrefix(), 0), max(rule.GetMaskSuffix(), 0))
+	}
+	return ""
+}
+
+func messageRule(desc protoreflect.MessageDescriptor) string {
+	s, _ := proto.GetExtension(desc.Options(), logjson.E_LogJsonMessage).(string)
+	if !logjson.ValidLogRule(s) {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethanvc/logjson"
+	"github.com/ethanvc/logjson/internal/testpb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func TestGeneratedCode(t *testing.T) {
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{testpb.File_internal_testpb_testpb_proto.Path()},
+		Parameter:      proto.String("paths=source_relative"),
+	}
+	addProtoFile(req, testpb.File_internal_testpb_testpb_proto, make(map[string]bool))
+	gen, err := protogen.Options{}.New(req)
+	require.NoError(t, err)
+	g := generateFile(gen, gen.FilesByPath[testpb.File_internal_testpb_testpb_proto.Path()], false)
+	content, err := g.Content()
+	require.NoError(t, err)
+	expected, err := os.ReadFile("../../internal/testpb/testpb_logjson.pb.go")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(content))
+}
+
+func addProtoFile(req *pluginpb.CodeGeneratorRequest, f protoreflect.FileDescriptor, seen map[string]bool) {
+	if seen[f.Path()] {
+		return
+	}
+	seen[f.Path()] = true
+	imports := f.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		addProtoFile(req, imports.Get(i).FileDescriptor, seen)
+	}
+	req.ProtoFile = append(req.ProtoFile, protodesc.ToFileDescriptorProto(f))
+}
+
+func TestGeneratedMatchesReflection(t *testing.T) {
+	extra, err := structpb.NewStruct(map[string]any{"b": 1, "a": "x"})
+	require.NoError(t, err)
+	orders := []*testpb.Order{
+		{},
+		{
+			Id:        1 << 60,
+			Name:      "hello",
+			Phone:     proto.String("13812345678"),
+			Password:  "password",
+			Ratio:     0.1,
+			Amount:    12.5,
+			Data:      []byte("hi"),
+			Paid:      true,
+			Big:       3,
+			Status:    testpb.Status_STATUS_PAID,
+			Items:     []*testpb.Item{{Sku: "sku", Note: "note", Count: 2}, {}},
+			Counts:    map[string]int32{"b": 2, "a": 1},
+			ItemsById: map[int64]*testpb.Item{10: {Sku: "x"}, -1: {Count: 1}},
+			Payload:   &testpb.Order_Item{Item: &testpb.Item{Sku: "y"}},
+			CreatedAt: timestamppb.New(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+			Extra:     extra,
+			Secret:    &testpb.Secret{Token: "token"},
+			Hidden:    &testpb.Hidden{Token: "token"},
+			Statuses:  []testpb.Status{testpb.Status_STATUS_FRAUD, testpb.Status(7)},
+			Flags:     map[bool]string{true: "t", false: "f"},
+		},
+		{Payload: &testpb.Order_Text{Text: "text"}, Status: testpb.Status_STATUS_FRAUD},
+	}
+	for _, order := range orders {
+		buf := bytes.NewBuffer(nil)
+		order.MarshalLogJSONState(logjson.NewEncoderState(buf))
+		require.Equal(t, string(logjson.NewLogJson().Marshal(dynamicMessage(order))), string(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))))
+	}
+}
+
+func dynamicMessage(m proto.Message) proto.Message {
+	dm := dynamicpb.NewMessage(m.ProtoReflect().Descriptor())
+	proto.Merge(dm, m)
+	return dm
+}
+
+func TestGeneratedLogJsonRules(t *testing.T) {
+	order := &testpb.Order{
+		Id:     1,
+		Amount: 12.5,
+		Phone:  proto.String("13812345678"),
+		Items:  []*testpb.Item{{Sku: "sku", Count: 2}},
+		Secret: &testpb.Secret{Token: "token"},
+	}
+	j := logjson.NewLogJson()
+	j.AddLogRule("amount", logjson.LogRuleOmit())
+	require.NotContains(t, string(j.Marshal(order)), `"amount"`)
+	require.Equal(t, string(j.Marshal(dynamicMessage(order))), string(j.Marshal(order)))
+	for _, opt := range []logjson.MarshalOption{
+		logjson.WithoutLogRules(),
+		logjson.WithLogRule("phone", logjson.LogRuleKeep()),
+		logjson.WithMaxDepth(2),
+	} {
+		require.Equal(t, string(logjson.NewLogJson().MarshalWith(dynamicMessage(order), opt)), string(logjson.NewLogJson().MarshalWith(order, opt)))
+	}
+	jsonNames := logjson.NewLogJson()
+	jsonNames.SetProtoJsonName(true)
+	require.Equal(t, string(jsonNames.Marshal(dynamicMessage(order))), string(jsonNames.Marshal(order)))
+}
+
+func TestGeneratedCycle(t *testing.T) {
+	extra := &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	extra.Fields["self"] = structpb.NewStructValue(extra)
+	order := &testpb.Order{Id: 1, Extra: extra}
+	j := logjson.NewLogJson()
+	j.SetCycleDetectionDepth(0)
+	require.Equal(t, `{"id":"1","extra":{"self":{"$ref":"$.extra"}}}`, string(j.Marshal(order)))
+	j.AddLogRule("name", logjson.LogRuleOmit())
+	require.Equal(t, `{"id":"1","extra":{"self":{"$ref":"$.extra"}}}`, string(j.Marshal(order)))
+}
+
+func TestFieldRuleInvalidLogJson(t *testing.T) {
+	msgOpts := &descriptorpb.MessageOptions{}
+	proto.SetExtension(msgOpts, logjson.E_LogJsonDefault, "sha256")
+	fieldOpts := &descriptorpb.FieldOptions{}
+	proto.SetExtension(fieldOpts, logjson.E_LogJson, "md55")
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:   proto.String("typo.proto"),
+		Syntax: proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name:    proto.String("Typo"),
+			Options: msgOpts,
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:    proto.String("name"),
+				Number:  proto.Int32(1),
+				Label:   descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:    descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Options: fieldOpts,
+			}},
+		}},
+	}, nil)
+	require.NoError(t, err)
+	desc := fd.Messages().Get(0)
+	require.Equal(t, "sha256", fieldRule(desc.Fields().Get(0)))
+	msg := dynamicpb.NewMessage(desc)
+	msg.Set(desc.Fields().Get(0), protoreflect.ValueOfString("hello"))
+	require.Equal(t, `{"name":"5;2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"}`, string(logjson.NewLogJson().Marshal(msg)))
+}
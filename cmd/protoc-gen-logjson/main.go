@@ -0,0 +1,39 @@
+// Command protoc-gen-logjson is a protoc plugin generating MarshalLogJSONState
+// methods for proto messages, so logjson marshals them without reflection.
+//
+// The generated code writes the same output as the protoreflect based path of
+// logjson and applies the log_json proto options statically. Messages are
+// written by the protoreflect based path when the LogJson of the call has
+// rules added by AddLogRule, AddTypeRule or a view, active grants, dedup
+// pointers or a SetProtoJsonName option other than json_names, or the call
+// has rules or depth options, see EncoderState.UseGeneratedProtoCode. Message
+// fields are written with the LogJson of the call.
+//
+// Usage:
+//
+//	protoc --logjson_out=. --logjson_opt=paths=source_relative foo.proto
+//
+// The json_names=true option makes the output use the json_name of fields,
+// like LogJson.SetProtoJsonName.
+package main
+
+import (
+	"flag"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+func main() {
+	var flags flag.FlagSet
+	jsonNames := flags.Bool("json_names", false, "use the json_name of fields")
+	protogen.Options{
+		ParamFunc: flags.Set,
+	}.Run(func(gen *protogen.Plugin) error {
+		for _, f := range gen.Files {
+			if f.Generate {
+				generateFile(gen, f, *jsonNames)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,17 @@
+// Command sensitivefields runs the sensitivefields analyzer, which reports
+// sensitive fields logged by logjson as is.
+//
+// Usage:
+//
+//	sensitivefields ./...
+//	go vet -vettool=$(which sensitivefields) ./...
+package main
+
+import (
+	"github.com/ethanvc/logjson/analysis/sensitivefields"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(sensitivefields.Analyzer)
+}
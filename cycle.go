@@ -0,0 +1,81 @@
+package logjson
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SetCycleDetectionDepth makes pointers, maps and slices checked for cycles
+// from the nesting depth depth, 0 checks all of them. A value within itself
+// is written as {"$ref":"$.parent.children[0]"}, naming the path of its
+// first occurrence. Checking costs time and memory for every pointer, so by
+// default cycles are detected from the depth 1000. A negative depth restores
+// the default.
+func (j *LogJson) SetCycleDetectionDepth(depth int) {
+	j.updateOptions(func(opts *logJsonOptions) {
+		opts.cycleDepth = depth
+		opts.cycleDepthSet = depth >= 0
+	})
+}
+
+func (opts logJsonOptions) cycleDetectionDepth() int {
+	if !opts.cycleDepthSet {
+		return startDetectingCyclesAfter
+	}
+	return opts.cycleDepth
+}
+
+// valuePath returns the path of the value to be written next, such as
+// $.parent.children[0], members whose names are not identifiers are written
+// like $["a.b"].
+func (state *EncoderState) valuePath() string {
+	depth := state.Encoder.StackDepth()
+	tokens := strings.Split(string(state.Encoder.StackPointer()), "/")[1:]
+	b := []byte("$")
+	for i := 1; i <= depth; i++ {
+		kind, length := state.Encoder.StackIndex(i)
+		switch {
+		case kind == '[' && i == depth:
+			// The pointer names the previous element, if any.
+			b = append(b, '[')
+			b = strconv.AppendInt(b, length, 10)
+			b = append(b, ']')
+		case kind == '[':
+			b = append(b, '[')
+			b = append(b, tokens[i-1]...)
+			b = append(b, ']')
+		default:
+			b = appendPathName(b, unescapePointerToken(tokens[i-1]))
+		}
+	}
+	return string(b)
+}
+
+func appendPathName(b []byte, name string) []byte {
+	if isPathIdentifier(name) {
+		b = append(b, '.')
+		return append(b, name...)
+	}
+	b = append(b, '[')
+	b = strconv.AppendQuote(b, name)
+	return append(b, ']')
+}
+
+func isPathIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c != '_' && c != '$' && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(i > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func unescapePointerToken(token string) string {
+	if !strings.Contains(token, "~") {
+		return token
+	}
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+}
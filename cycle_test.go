@@ -0,0 +1,35 @@
+package logjson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogJson_CycleDetectionDepth(t *testing.T) {
+	type Node struct {
+		Name     string
+		Parent   *Node
+		Children []*Node
+		Attrs    map[string]any
+	}
+	root := &Node{Name: "root"}
+	child := &Node{Name: "child", Parent: root}
+	root.Children = []*Node{child}
+	root.Attrs = map[string]any{"a.b": root.Children, "self": root.Attrs}
+	j := NewLogJson()
+	j.SetSortMapKeys(true)
+	j.SetCycleDetectionDepth(0)
+	require.Equal(t, `{"Name":"root","Parent":null,"Children":[{"Name":"child","Parent":{"$ref":"$"},"Children":null,"Attrs":null}],"Attrs":{"a.b":[{"Name":"child","Parent":{"$ref":"$"},"Children":null,"Attrs":null}],"self":null}}`,
+		string(j.Marshal(root)))
+	root.Attrs = map[string]any{"a.b": root.Children}
+	root.Attrs["self"] = root.Attrs
+	require.Equal(t, `{"Name":"root","Parent":null,"Children":[{"Name":"child","Parent":{"$ref":"$"},"Children":null,"Attrs":null}],"Attrs":{"a.b":[{"Name":"child","Parent":{"$ref":"$"},"Children":null,"Attrs":null}],"self":{"$ref":"$.Attrs"}}}`,
+		string(j.Marshal(root)))
+	child.Children = root.Children
+	require.Contains(t, string(j.Marshal(root)), `"Children":[{"Name":"child","Parent":{"$ref":"$"},"Children":{"$ref":"$.Children"}`)
+	require.Contains(t, string(j.Marshal([]any{map[string]any{"x/y": child}})), `[{"x/y":{"Name":"child","Parent":{"Name":"root","Parent":null,"Children":[{"$ref":"$[0][\"x/y\"]"}`)
+	child.Children, root.Attrs = nil, nil
+	j.SetCycleDetectionDepth(-1)
+	require.Contains(t, string(j.Marshal(root)), `"Parent":{"Name":"root","Parent":null,"Children":[{"Name":"child","Parent":{"Name":"root"`)
+}
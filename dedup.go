@@ -0,0 +1,120 @@
+package logjson
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+// dedupEntrySize is the estimated memory of a value recorded in the dedup
+// mode, in bytes.
+const dedupEntrySize = 64
+
+// SetDedupPointers makes every struct pointed to, map and slice written once
+// per marshal call, its first occurrence gets an $id, such as
+// {"$id":1,"Name":"tenant"}, and the later ones refer to it with
+// {"$idref":1}. Slices are wrapped as {"$id":1,"$values":[...]}. Structs and
+// maps having members named "$id", "$idref" or "$values" are always written
+// in full, so they are not mistaken for the markers. budget is the memory in
+// bytes for the values recorded by a marshal call, values beyond it are
+// written in full. A budget of 0, the default, disables the mode.
+func (j *LogJson) SetDedupPointers(budget int) {
+	j.updateOptions(func(opts *logJsonOptions) {
+		opts.dedupBudget = budget
+	})
+}
+
+// dedupLimit returns the number of values recorded in the dedup mode, 0 when
+// the mode is disabled.
+func (opts logJsonOptions) dedupLimit() int {
+	return max(opts.dedupBudget, 0) / dedupEntrySize
+}
+
+// dedupNames are the member names written by the dedup mode, the $ref of
+// cycles is another marker, see writeCycleRef.
+var dedupNames = []string{"$id", "$idref", "$values"}
+
+// hasDedupName reports whether names holds one of dedupNames.
+func hasDedupName(names []string) bool {
+	for _, name := range names {
+		if slices.Contains(dedupNames, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// mapHasDedupName reports whether a key of the map v is written as one of
+// dedupNames, uniqueKeys is the result of mapKeysUnique.
+func mapHasDedupName(v reflect.Value, keyStringify mapKeyStringifyFunc, uniqueKeys bool, state *EncoderState) bool {
+	keyType := v.Type().Key()
+	if uniqueKeys {
+		if keyType.Kind() != reflect.String {
+			return false
+		}
+		for _, name := range dedupNames {
+			if v.MapIndex(reflect.ValueOf(name).Convert(keyType)).IsValid() {
+				return true
+			}
+		}
+		return false
+	}
+	for iter := v.MapRange(); iter.Next(); {
+		if slices.Contains(dedupNames, keyStringify(iter.Key(), state)) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeDedupRef writes {"$idref":id} and reports true when the value of key
+// has been written with an $id already.
+func (state *EncoderState) writeDedupRef(key valueId) bool {
+	id, ok := state.dedupIds[key]
+	if !ok {
+		return false
+	}
+	state.Encoder.WriteToken(jsontext.ObjectStart)
+	state.Encoder.WriteToken(jsontext.String("$idref"))
+	state.Encoder.WriteToken(jsontext.Int(int64(id)))
+	state.Encoder.WriteToken(jsontext.ObjectEnd)
+	return true
+}
+
+// addDedupId records the value of key and returns its $id, or 0 when limit
+// values are recorded already.
+func (state *EncoderState) addDedupId(key valueId, limit int) int {
+	if len(state.dedupIds) >= limit {
+		return 0
+	}
+	if state.dedupIds == nil {
+		state.dedupIds = make(map[valueId]int)
+	}
+	id := len(state.dedupIds) + 1
+	state.dedupIds[key] = id
+	return id
+}
+
+func (state *EncoderState) writeDedupId(id int) {
+	state.Encoder.WriteToken(jsontext.String("$id"))
+	state.Encoder.WriteToken(jsontext.Int(int64(id)))
+}
+
+// writePendingDedupId writes the $id of the struct v as its first member when
+// v is the struct pointed to by the pointer handler setting dedupPending.
+// Structs written by marshalers and structs with fields of dedupNames, which
+// is reported by skip, do not get an $id, so they are not referred to.
+func (state *EncoderState) writePendingDedupId(v reflect.Value, skip bool) {
+	key := state.dedupPending
+	if key.t == nil || !v.CanAddr() || key.t.Elem() != v.Type() || key.p != any(v.Addr().UnsafePointer()) {
+		return
+	}
+	state.dedupPending = valueId{}
+	if skip {
+		return
+	}
+	if id := state.addDedupId(key, state.dedupPendingLimit); id != 0 {
+		state.writeDedupId(id)
+	}
+}
@@ -0,0 +1,54 @@
+package logjson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogJson_DedupPointers(t *testing.T) {
+	type Tenant struct {
+		Name string
+		Self *Tenant
+	}
+	type Item struct {
+		Tenant *Tenant
+		Tags   []string
+		Attrs  map[string]int
+	}
+	tenant := &Tenant{Name: "t"}
+	tags := []string{"a"}
+	attrs := map[string]int{"x": 1}
+	items := []Item{{tenant, tags, attrs}, {tenant, tags, attrs}, {Tenant: &Tenant{Name: "u"}}}
+	j := NewLogJson()
+	require.Equal(t, `[{"Tenant":{"Name":"t","Self":null},"Tags":["a"],"Attrs":{"x":1}},{"Tenant":{"Name":"t","Self":null},"Tags":["a"],"Attrs":{"x":1}},{"Tenant":{"Name":"u","Self":null},"Tags":null,"Attrs":null}]`,
+		string(j.Marshal(items)))
+	j.SetDedupPointers(1 << 20)
+	require.Equal(t, `{"$id":1,"$values":[{"Tenant":{"$id":2,"Name":"t","Self":null},"Tags":{"$id":3,"$values":["a"]},"Attrs":{"$id":4,"x":1}},{"Tenant":{"$idref":2},"Tags":{"$idref":3},"Attrs":{"$idref":4}},{"Tenant":{"$id":5,"Name":"u","Self":null},"Tags":null,"Attrs":null}]}`,
+		string(j.Marshal(items)))
+	tenant.Self = tenant
+	require.Equal(t, `{"$id":1,"Name":"t","Self":{"$idref":1}}`, string(j.Marshal(tenant)))
+	require.Equal(t, `{"Name":"t","Self":{"$id":1,"Name":"t","Self":{"$idref":1}}}`, string(j.Marshal(*tenant)))
+	j.SetDedupPointers(2 * dedupEntrySize)
+	require.Equal(t, `{"$id":1,"$values":[{"Tenant":{"$id":2,"Name":"t","Self":{"$idref":2}},"Tags":["a"],"Attrs":{"x":1}},{"Tenant":{"$idref":2},"Tags":["a"],"Attrs":{"x":1}},{"Tenant":{"Name":"u","Self":null},"Tags":null,"Attrs":null}]}`,
+		string(j.Marshal(items)))
+}
+
+func TestLogJson_DedupPointersMemberNames(t *testing.T) {
+	type Node struct {
+		Id   int `json:"$id"`
+		Name string
+	}
+	type Abc struct {
+		A, B *Node
+		C, D map[string]int
+		E, F map[any]int
+	}
+	node := &Node{Id: 7, Name: "n"}
+	m := map[string]int{"$idref": 1}
+	anyMap := map[any]int{"$values": 1}
+	j := NewLogJson()
+	j.SetDedupPointers(1 << 20)
+	require.Equal(t, `{"A":{"$id":7,"Name":"n"},"B":{"$id":7,"Name":"n"},"C":{"$idref":1},"D":{"$idref":1},"E":{"$values":1},"F":{"$values":1}}`,
+		string(j.Marshal(Abc{A: node, B: node, C: m, D: m, E: anyMap, F: anyMap})))
+}
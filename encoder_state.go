@@ -1,6 +1,7 @@
 package logjson
 
 import (
+	"context"
 	"github.com/go-json-experiment/json/jsontext"
 	"io"
 	"reflect"
@@ -8,8 +9,26 @@ import (
 
 type EncoderState struct {
 	*jsontext.Encoder
+	// logJson is the LogJson marshaling with the state.
+	logJson *LogJson
 	w       io.Writer
-	visited map[valueId]struct{}
+	// visited maps the pointers being marshaled to the paths of their first
+	// occurrences, see SetCycleDetectionDepth.
+	visited    map[valueId]string
+	opts       marshalOptions
+	hasOptions bool
+	// curStruct is the struct whose fields are being marshaled.
+	curStruct reflect.Value
+	curFields []structField
+	// dedupIds are the $ids of the values written in the dedup mode, see
+	// SetDedupPointers. dedupPending is the pointer whose struct gets the next
+	// $id, if its limit of values allows.
+	dedupIds          map[valueId]int
+	dedupPending      valueId
+	dedupPendingLimit int
+	// mapEntries is the scratch buffer sorting map entries, it is kept by
+	// Reset.
+	mapEntries []mapEntry
 }
 
 func NewEncoderState(w io.Writer) *EncoderState {
@@ -27,7 +46,50 @@ func (state *EncoderState) GetWriter() io.Writer {
 func (state *EncoderState) Reset(w io.Writer) {
 	state.Encoder.Reset(w)
 	state.w = w
+	state.logJson = nil
 	state.visited = nil
+	state.dedupIds = nil
+	state.dedupPending = valueId{}
+	state.opts = marshalOptions{}
+	state.hasOptions = false
+	state.curStruct = reflect.Value{}
+	state.curFields = nil
+}
+
+// SetOptions applies opts to everything marshaled with the state until the
+// next Reset.
+func (state *EncoderState) SetOptions(opts ...MarshalOption) {
+	for _, opt := range opts {
+		opt(&state.opts)
+		state.hasOptions = true
+	}
+}
+
+// Context returns the context set by WithContext, or context.Background.
+func (state *EncoderState) Context() context.Context {
+	if state.opts.ctx == nil {
+		return context.Background()
+	}
+	return state.opts.ctx
+}
+
+// LogJson returns the LogJson marshaling with the state, or DefaultLogJson
+// when the state is not used by a LogJson.
+func (state *EncoderState) LogJson() *LogJson {
+	if state.logJson == nil {
+		return DefaultLogJson()
+	}
+	return state.logJson
+}
+
+// MarshalValue writes in with the LogJson of the state, for StateLogMarshaler
+// implementations marshaling their sub-values.
+func (state *EncoderState) MarshalValue(in any) {
+	state.LogJson().MarshalWithState(in, state)
+}
+
+func (state *EncoderState) exceedMaxDepth() bool {
+	return state.opts.maxDepth > 0 && state.Encoder.StackDepth() >= state.opts.maxDepth
 }
 
 func (state *EncoderState) enterPointer(v reflect.Value) bool {
@@ -36,13 +98,23 @@ func (state *EncoderState) enterPointer(v reflect.Value) bool {
 		return false
 	} else {
 		if state.visited == nil {
-			state.visited = make(map[valueId]struct{})
+			state.visited = make(map[valueId]string)
 		}
-		state.visited[key] = struct{}{}
+		state.visited[key] = state.valuePath()
 		return true
 	}
 }
 
+// writeCycleRef writes {"$ref":path} for v being marshaled already, where
+// path is the path of v recorded by enterPointer.
+func (state *EncoderState) writeCycleRef(v reflect.Value) {
+	key := valueId{v.Type(), v.UnsafePointer(), state.sliceLen(v)}
+	state.Encoder.WriteToken(jsontext.ObjectStart)
+	state.Encoder.WriteToken(jsontext.String("$ref"))
+	state.Encoder.WriteToken(jsontext.String(state.visited[key]))
+	state.Encoder.WriteToken(jsontext.ObjectEnd)
+}
+
 func (state *EncoderState) sliceLen(v reflect.Value) int {
 	if v.Kind() == reflect.Slice {
 		return v.Len()
@@ -60,3 +132,14 @@ func (state *EncoderState) leavePointer(v reflect.Value) {
 	key := valueId{v.Type(), v.UnsafePointer(), state.sliceLen(v)}
 	delete(state.visited, key)
 }
+
+// structFieldValue returns the value of the field named name of the struct
+// being marshaled.
+func (state *EncoderState) structFieldValue(name string) (reflect.Value, bool) {
+	for i := range state.curFields {
+		if state.curFields[i].Name == name {
+			return state.curStruct.FieldByIndex(state.curFields[i].Index), true
+		}
+	}
+	return reflect.Value{}, false
+}
@@ -0,0 +1,127 @@
+package logjson
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+// EnumFormat is how values of named scalar types with String methods, such
+// as enums, are written. See SetEnumFormat and LogRuleEnum.
+type EnumFormat int
+
+const (
+	// EnumNumber writes the values as they are, the default.
+	EnumNumber EnumFormat = iota
+	// EnumName writes the results of String.
+	EnumName
+	// EnumBoth writes objects such as {"code":3,"name":"PAID"}.
+	EnumBoth
+)
+
+var enumFormatNames = map[string]EnumFormat{
+	"number": EnumNumber,
+	"name":   EnumName,
+	"both":   EnumBoth,
+}
+
+// SetEnumFormat sets the format of named bools, numbers and strings which
+// implement fmt.Stringer. Types implementing LogMarshaler, error or the other
+// marshalers of logjson are not changed.
+func (j *LogJson) SetEnumFormat(format EnumFormat) {
+	j.updateOptions(func(opts *logJsonOptions) {
+		opts.enumFormat = format
+	})
+}
+
+// LogRuleEnum writes values of named scalar types with String methods with
+// format. It is "enum=number", "enum=name" or "enum=both" in log tags.
+func LogRuleEnum(format EnumFormat) LogRule {
+	return func(conf *logRuleConf) {
+		conf.name = "enum"
+		conf.enumFormat = &format
+	}
+}
+
+// isEnumType reports whether t is a named bool, number or string type whose
+// values or pointers implement fmt.Stringer and no marshaler of logjson.
+func isEnumType(t reflect.Type) bool {
+	if t.Name() == "" || t.PkgPath() == "" {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+	default:
+		return false
+	}
+	if marshalerIntIndex(reflect.PointerTo(t)) < len(marshalerIntTypes) {
+		return false
+	}
+	return reflect.PointerTo(t).Implements(stringerIntType)
+}
+
+// createEnumMarshal returns a marshal func writing values of t with format,
+// it returns nil when t is not an enum type.
+func createEnumMarshal(t reflect.Type, format EnumFormat) func(v reflect.Value, state *EncoderState) {
+	if t.Kind() == reflect.Pointer {
+		marshal := createEnumMarshal(t.Elem(), format)
+		if marshal == nil {
+			return nil
+		}
+		return func(v reflect.Value, state *EncoderState) {
+			if v.IsNil() {
+				state.Encoder.WriteToken(jsontext.Null)
+				return
+			}
+			marshal(v.Elem(), state)
+		}
+	}
+	if !isEnumType(t) {
+		return nil
+	}
+	addr := !t.Implements(stringerIntType)
+	name := func(v reflect.Value) string {
+		if addr {
+			tmp := reflect.New(t)
+			tmp.Elem().Set(v)
+			v = tmp
+		}
+		return v.Interface().(fmt.Stringer).String()
+	}
+	switch format {
+	case EnumName:
+		return func(v reflect.Value, state *EncoderState) {
+			state.Encoder.WriteToken(jsontext.String(name(v)))
+		}
+	case EnumBoth:
+		return func(v reflect.Value, state *EncoderState) {
+			state.Encoder.WriteToken(jsontext.ObjectStart)
+			state.Encoder.WriteToken(jsontext.String("code"))
+			writeScalar(v, state)
+			state.Encoder.WriteToken(jsontext.String("name"))
+			state.Encoder.WriteToken(jsontext.String(name(v)))
+			state.Encoder.WriteToken(jsontext.ObjectEnd)
+		}
+	}
+	return writeScalar
+}
+
+// writeScalar writes bools, numbers and strings as they are.
+func writeScalar(v reflect.Value, state *EncoderState) {
+	switch v.Kind() {
+	case reflect.Bool:
+		state.Encoder.WriteToken(jsontext.Bool(v.Bool()))
+	case reflect.String:
+		state.Encoder.WriteToken(jsontext.String(v.String()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		state.Encoder.WriteToken(jsontext.Int(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		state.Encoder.WriteToken(jsontext.Uint(v.Uint()))
+	case reflect.Float32, reflect.Float64:
+		writeFloat(state.Encoder, v.Float(), v.Type().Bits(), -1)
+	}
+}
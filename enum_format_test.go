@@ -0,0 +1,55 @@
+package logjson
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testOrderStatus int
+
+func (s testOrderStatus) String() string {
+	if s == 3 {
+		return "PAID"
+	}
+	return "UNKNOWN"
+}
+
+type testColor string
+
+func (c *testColor) String() string {
+	return "color:" + string(*c)
+}
+
+type testErrCode int
+
+func (c testErrCode) String() string { return "code" }
+
+func (c testErrCode) Error() string { return "err" }
+
+func TestLogJson_EnumFormat(t *testing.T) {
+	type Abc struct {
+		Status  testOrderStatus
+		Color   testColor
+		Code    testErrCode
+		Ptr     *testOrderStatus
+		Tagged  testOrderStatus  `log:"enum=both"`
+		Number  testOrderStatus  `log:"enum=number"`
+		NilPtr  *testOrderStatus `log:"enum=name"`
+		Plain   int              `log:"enum=name"`
+		Wrapped error
+	}
+	status := testOrderStatus(3)
+	abc := Abc{Status: 3, Color: "red", Code: 1, Ptr: &status, Tagged: 3, Number: 3, Plain: 3, Wrapped: errors.New("e")}
+	j := NewLogJson()
+	require.Equal(t, `{"Status":3,"Color":"red","Code":"err","Ptr":3,"Tagged":{"code":3,"name":"PAID"},"Number":3,"NilPtr":null,"Plain":3,"Wrapped":"e"}`,
+		string(j.Marshal(abc)))
+	j.SetEnumFormat(EnumName)
+	require.Equal(t, `{"Status":"PAID","Color":"color:red","Code":"err","Ptr":"PAID","Tagged":{"code":3,"name":"PAID"},"Number":3,"NilPtr":null,"Plain":3,"Wrapped":"e"}`,
+		string(j.Marshal(abc)))
+	j.SetEnumFormat(EnumBoth)
+	require.Equal(t, `{"code":"red","name":"color:red"}`, string(j.Marshal(testColor("red"))))
+	require.Equal(t, `[{"code":0,"name":"UNKNOWN"}]`, string(j.Marshal([]testOrderStatus{0})))
+	require.False(t, ValidLogRule("enum=text"))
+}
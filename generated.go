@@ -0,0 +1,132 @@
+package logjson
+
+import (
+	"reflect"
+
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+// This file contains the API used by the code generated by
+// protoc-gen-logjson and logjsongen.
+
+// StringRule applies a rule of log tags or log_json proto options to string
+// values outside of LogJson.
+type StringRule struct {
+	conf *logRuleConf
+}
+
+// NewStringRule parses ruleStr like a log tag, it panics when ruleStr is not
+// a valid rule.
+func NewStringRule(ruleStr string) *StringRule {
+	conf := newLogRuleConfFromStr(ruleStr)
+	if conf == nil {
+		panic("logjson: invalid rule " + ruleStr)
+	}
+	return &StringRule{conf: conf}
+}
+
+// Apply returns s with the rule applied, omit rules are handled by the caller.
+func (r *StringRule) Apply(s string) string {
+	s, _ = r.conf.applyString(s)
+	return s
+}
+
+// ApplyBytes returns the string b is written as by a bytes rule, such as
+// "bytes=hex", other rules write b as BytesBase64Raw.
+func (r *StringRule) ApplyBytes(b []byte) string {
+	if r.conf.bytes != nil {
+		return r.conf.bytes.Format(b)
+	}
+	return BytesBase64Raw.Format(b)
+}
+
+// WriteInlineJSON writes s with a "json" rule, as embedded JSON with the rules
+// of the LogJson of state and of the call applied to its members, invalid
+// JSON is written as a string.
+func (r *StringRule) WriteInlineJSON(state *EncoderState, s string) {
+	inlineJSONStringMarshal(reflect.ValueOf(s), state)
+}
+
+var inlineJSONStringMarshal = createInlineJSONMarshal(reflect.TypeFor[string](), false)
+
+// WriteValue writes v with the rule applied as to a struct field of the type
+// of v, such as "enum=name" to enums, with the LogJson of state marshaling v
+// when the rule does not change it.
+func (r *StringRule) WriteValue(state *EncoderState, v any) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		state.Encoder.WriteToken(jsontext.Null)
+		return
+	}
+	item := r.conf.getHandlerItem(rv.Type(), nil)
+	if item == nil {
+		state.MarshalValue(v)
+		return
+	}
+	item.marshal(rv, state)
+}
+
+// UseGeneratedCode reports whether the code generated by logjsongen may write
+// the fields of its types by their log tags alone, which is when the LogJson
+// of the state has no rules added by AddLogRule, AddTypeRule or a view, no
+// active grants, no SetDedupPointers and no SetInlineJSON, and the call has no
+// rules or depth options. The generated code calls MarshalReflect otherwise.
+func (state *EncoderState) UseGeneratedCode() bool {
+	return !state.hasRuleOptions() && state.LogJson().hasOnlyFieldRules() && !state.LogJson().getOptions().inlineJSON
+}
+
+// UseGeneratedProtoCode is UseGeneratedCode for the code generated by
+// protoc-gen-logjson, jsonNames reports whether the code writes the json_name
+// of fields, which must match SetProtoJsonName. SetInlineJSON does not change
+// proto messages.
+func (state *EncoderState) UseGeneratedProtoCode(jsonNames bool) bool {
+	return !state.hasRuleOptions() && state.LogJson().hasOnlyFieldRules() && state.LogJson().getOptions().protoJsonName == jsonNames
+}
+
+// hasRuleOptions reports whether the call has rules or depth options.
+func (state *EncoderState) hasRuleOptions() bool {
+	return state.hasOptions && (state.opts.ignoreLogRules || len(state.opts.logRules) > 0 || state.opts.maxDepth > 0)
+}
+
+// hasOnlyFieldRules reports whether the output of j depends on the rules of
+// log tags and proto options only.
+func (j *LogJson) hasOnlyFieldRules() bool {
+	j.mux.Lock()
+	hasRules := len(j.logRules) > 0 || len(j.typeRules) > 0
+	opts := j.opts
+	j.mux.Unlock()
+	return !hasRules && !j.ignoreFieldRules && opts.dedupLimit() == 0 && !j.grants.active()
+}
+
+// MarshalReflect writes in with the LogJson of the state like the types
+// without generated code, the marshaler methods of the type of in are
+// ignored. It is called by generated code when UseGeneratedCode reports false.
+func (state *EncoderState) MarshalReflect(in any) {
+	v := reflect.ValueOf(in)
+	if !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+		state.Encoder.WriteToken(jsontext.Null)
+		return
+	}
+	j := state.LogJson()
+	prevLogJson := state.logJson
+	state.logJson = j
+	j.getReflectHandlerItem(v.Type()).marshal(v, state)
+	state.logJson = prevLogJson
+}
+
+// MarshalEncoder writes in to enc with the rules of j.
+func (j *LogJson) MarshalEncoder(in any, enc *jsontext.Encoder) {
+	j.MarshalWithState(in, &EncoderState{Encoder: enc})
+}
+
+// WriteFloat writes f formatted with the precision of bits, so a float32 0.1
+// is written as 0.1 instead of 0.10000000149011612.
+func WriteFloat(enc *jsontext.Encoder, f float64, bits int) {
+	writeFloat(enc, f, bits, -1)
+}
+
+// ValidLogRule reports whether ruleStr is a valid rule of log tags and
+// log_json proto options.
+func ValidLogRule(ruleStr string) bool {
+	return newLogRuleConfFromStr(ruleStr) != nil
+}
@@ -0,0 +1,191 @@
+package logjson
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Grant bypasses a rule until ExpiresAt, so the clear value of a field can be
+// logged while debugging without a code change.
+type Grant struct {
+	// Field is the name of the field whose rule is bypassed, empty matches
+	// any field. Rules of types and messages use TypeRuleKey and
+	// MessageRuleKey.
+	Field string
+	// Rule is the name of the bypassed rule, such as "md5", empty matches
+	// any rule.
+	Rule string
+	// Predicate restricts the grant to some calls, such as the calls for a
+	// single user, see WithContext. Nil matches all calls.
+	Predicate func(ctx context.Context) bool
+	ExpiresAt time.Time
+	// Reason is recorded in the audit records of the grant.
+	Reason string
+}
+
+type GrantEvent string
+
+const (
+	GrantCreated GrantEvent = "created"
+	GrantExpired GrantEvent = "expired"
+	GrantRevoked GrantEvent = "revoked"
+)
+
+// GrantAuditRecord is emitted when a grant is created, expires or is revoked.
+type GrantAuditRecord struct {
+	Event     GrantEvent
+	GrantId   uint64
+	Field     string
+	Rule      string
+	ExpiresAt time.Time
+	Reason    string
+	Time      time.Time
+}
+
+// GrantAuditor receives the audit records of grants.
+type GrantAuditor func(record GrantAuditRecord)
+
+var grantsDisabled atomic.Bool
+
+// DisableGrants is the global kill switch of grants. While disabled, all
+// grants of all LogJson are ignored and AddGrant fails.
+func DisableGrants(disable bool) {
+	grantsDisabled.Store(disable)
+}
+
+var errGrantsDisabled = errors.New("logjson: grants are disabled")
+
+// AddGrant activates g until it expires or the returned revoke function is
+// called. A LogJson and its views share their grants and grant auditor.
+func (j *LogJson) AddGrant(g Grant) (revoke func(), err error) {
+	return j.grants.add(g)
+}
+
+// SetGrantAuditor sets the receiver of grant audit records, by default they are
+// written with the standard log package.
+func (j *LogJson) SetGrantAuditor(auditor GrantAuditor) {
+	j.grants.setAuditor(auditor)
+}
+
+type grantRegistry struct {
+	mux     sync.RWMutex
+	n       atomic.Int32
+	nextId  uint64
+	grants  map[uint64]*activeGrant
+	auditor GrantAuditor
+}
+
+type activeGrant struct {
+	id    uint64
+	grant Grant
+	timer *time.Timer
+}
+
+func newGrantRegistry() *grantRegistry {
+	return &grantRegistry{
+		grants: make(map[uint64]*activeGrant),
+	}
+}
+
+func (r *grantRegistry) add(g Grant) (func(), error) {
+	if grantsDisabled.Load() {
+		return nil, errGrantsDisabled
+	}
+	if g.Field == "" && g.Rule == "" {
+		return nil, errors.New("logjson: grant must name a field or a rule")
+	}
+	d := time.Until(g.ExpiresAt)
+	if d <= 0 {
+		return nil, errors.New("logjson: grant is already expired")
+	}
+	r.mux.Lock()
+	r.nextId++
+	ag := &activeGrant{id: r.nextId, grant: g}
+	r.grants[ag.id] = ag
+	r.n.Store(int32(len(r.grants)))
+	ag.timer = time.AfterFunc(d, func() {
+		r.remove(ag.id, GrantExpired)
+	})
+	r.mux.Unlock()
+	r.audit(GrantCreated, ag)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ag.timer.Stop()
+			r.remove(ag.id, GrantRevoked)
+		})
+	}, nil
+}
+
+func (r *grantRegistry) remove(id uint64, event GrantEvent) {
+	r.mux.Lock()
+	ag, ok := r.grants[id]
+	delete(r.grants, id)
+	r.n.Store(int32(len(r.grants)))
+	r.mux.Unlock()
+	if ok {
+		r.audit(event, ag)
+	}
+}
+
+func (r *grantRegistry) setAuditor(auditor GrantAuditor) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.auditor = auditor
+}
+
+func (r *grantRegistry) audit(event GrantEvent, ag *activeGrant) {
+	record := GrantAuditRecord{
+		Event:     event,
+		GrantId:   ag.id,
+		Field:     ag.grant.Field,
+		Rule:      ag.grant.Rule,
+		ExpiresAt: ag.grant.ExpiresAt,
+		Reason:    ag.grant.Reason,
+		Time:      time.Now(),
+	}
+	r.mux.RLock()
+	auditor := r.auditor
+	r.mux.RUnlock()
+	if auditor == nil {
+		log.Printf("logjson grant audit: %s", Marshal(record))
+		return
+	}
+	auditor(record)
+}
+
+// active reports whether grants may bypass rules.
+func (r *grantRegistry) active() bool {
+	return r.n.Load() > 0 && !grantsDisabled.Load()
+}
+
+// bypass reports whether an active grant bypasses conf of field for the call.
+func (r *grantRegistry) bypass(field string, conf *logRuleConf, state *EncoderState) bool {
+	if !r.active() {
+		return false
+	}
+	now := time.Now()
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	for _, ag := range r.grants {
+		g := &ag.grant
+		if g.Field != "" && g.Field != field {
+			continue
+		}
+		if g.Rule != "" && g.Rule != conf.name {
+			continue
+		}
+		if !now.Before(g.ExpiresAt) {
+			continue
+		}
+		if g.Predicate != nil && !g.Predicate(state.Context()) {
+			continue
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package logjson
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testUserKey struct{}
+
+func TestLogJson_Grant(t *testing.T) {
+	type Abc struct {
+		Name string `log:"md5"`
+	}
+	j := NewLogJson()
+	var records []GrantAuditRecord
+	j.SetGrantAuditor(func(record GrantAuditRecord) {
+		records = append(records, record)
+	})
+	revoke, err := j.AddGrant(Grant{
+		Field: "Name",
+		Predicate: func(ctx context.Context) bool {
+			return ctx.Value(testUserKey{}) == "u1"
+		},
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	abc := Abc{Name: "hello"}
+	ctx := context.WithValue(context.Background(), testUserKey{}, "u1")
+	require.Equal(t, `{"Name":"hello"}`, string(j.MarshalContext(ctx, abc)))
+	require.Equal(t, `{"Name":"5;5d41402abc4b2a76b9719d911017c592"}`, string(j.Marshal(abc)))
+
+	DisableGrants(true)
+	require.Equal(t, `{"Name":"5;5d41402abc4b2a76b9719d911017c592"}`, string(j.MarshalContext(ctx, abc)))
+	DisableGrants(false)
+
+	revoke()
+	require.Equal(t, `{"Name":"5;5d41402abc4b2a76b9719d911017c592"}`, string(j.MarshalContext(ctx, abc)))
+	require.Len(t, records, 2)
+	require.Equal(t, GrantCreated, records[0].Event)
+	require.Equal(t, GrantRevoked, records[1].Event)
+}
+
+func TestLogJson_GrantExpire(t *testing.T) {
+	type Abc struct {
+		Name string
+	}
+	j := NewLogJson()
+	j.AddLogRule("Name", LogRuleOmit())
+	expired := make(chan GrantAuditRecord, 1)
+	j.SetGrantAuditor(func(record GrantAuditRecord) {
+		if record.Event == GrantExpired {
+			expired <- record
+		}
+	})
+	_, err := j.AddGrant(Grant{Rule: "omit", ExpiresAt: time.Now().Add(50 * time.Millisecond)})
+	require.NoError(t, err)
+	require.Equal(t, `{"Name":"hello"}`, string(j.Marshal(Abc{Name: "hello"})))
+	<-expired
+	require.Equal(t, `{}`, string(j.Marshal(Abc{Name: "hello"})))
+}
+
+func TestLogJson_GrantView(t *testing.T) {
+	type Abc struct {
+		Name  string `log:"md5"`
+		Phone string
+	}
+	j := NewLogJson()
+	var records []GrantAuditRecord
+	j.SetGrantAuditor(func(record GrantAuditRecord) {
+		records = append(records, record)
+	})
+	view := j.View(NewLogPolicy().AddLogRule("Phone", LogRuleOmit()))
+	abc := Abc{Name: "hello", Phone: "123"}
+	require.Equal(t, `{"Name":"5;5d41402abc4b2a76b9719d911017c592"}`, string(view.Marshal(abc)))
+	revokeName, err := j.AddGrant(Grant{Field: "Name", ExpiresAt: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	require.Equal(t, `{"Name":"hello"}`, string(view.Marshal(abc)))
+	revokePhone, err := view.AddGrant(Grant{Field: "Phone", ExpiresAt: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	require.Equal(t, `{"Name":"hello","Phone":"123"}`, string(view.Marshal(abc)))
+	revokeName()
+	revokePhone()
+	require.Equal(t, `{"Name":"5;5d41402abc4b2a76b9719d911017c592"}`, string(view.Marshal(abc)))
+	require.Len(t, records, 4)
+}
@@ -0,0 +1,132 @@
+package logjson
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+// SetInlineJSON makes json.RawMessage and jsontext.Value values, and strings
+// holding JSON objects or arrays, written as embedded JSON instead of base64
+// or escaped strings. Rules of names apply to the members of embedded
+// objects like to struct fields, rules changing strings apply to the JSON text
+// of members which are not strings. Invalid JSON is written as a string.
+func (j *LogJson) SetInlineJSON(inline bool) {
+	j.updateOptions(func(opts *logJsonOptions) {
+		opts.inlineJSON = inline
+	})
+}
+
+// LogRuleInlineJSON writes string, []byte and json.RawMessage values holding
+// JSON as embedded JSON, like SetInlineJSON does for json.RawMessage. It is
+// "json" in log tags.
+func LogRuleInlineJSON() LogRule {
+	return func(conf *logRuleConf) {
+		conf.name = "json"
+		conf.inlineJSON = true
+	}
+}
+
+var rawMessageType = reflect.TypeFor[stdjson.RawMessage]()
+var jsontextValueType = reflect.TypeFor[jsontext.Value]()
+
+func isRawJSONType(t reflect.Type) bool {
+	return t == rawMessageType || t == jsontextValueType
+}
+
+// createInlineJSONMarshal returns a marshal func embedding the JSON of string
+// and []byte values of type t, it returns nil for other types. Strings are
+// embedded only when they hold objects or arrays if containersOnly is set.
+func createInlineJSONMarshal(t reflect.Type, containersOnly bool) func(v reflect.Value, state *EncoderState) {
+	var getBytes func(v reflect.Value) []byte
+	switch {
+	case t.Kind() == reflect.String:
+		getBytes = func(v reflect.Value) []byte {
+			return []byte(v.String())
+		}
+	case isBytesType(t):
+		getBytes = reflect.Value.Bytes
+	default:
+		return nil
+	}
+	return func(v reflect.Value, state *EncoderState) {
+		b := getBytes(v)
+		if containersOnly && t.Kind() == reflect.String && !isJSONContainer(b) {
+			state.Encoder.WriteToken(jsontext.String(v.String()))
+			return
+		}
+		if !jsontext.Value(b).IsValid() {
+			state.Encoder.WriteToken(jsontext.String(strings.ToValidUTF8(string(b), "�")))
+			return
+		}
+		dec := jsontext.NewDecoder(bytes.NewReader(b))
+		state.LogJson().copyJSONValue(dec, state)
+	}
+}
+
+func isJSONContainer(b []byte) bool {
+	b = bytes.TrimLeft(b, " \t\r\n")
+	return len(b) > 0 && (b[0] == '{' || b[0] == '[')
+}
+
+// copyJSONValue copies the next value of dec, which is valid, to the encoder
+// of state and applies the rules of j to the members of objects.
+func (j *LogJson) copyJSONValue(dec *jsontext.Decoder, state *EncoderState) {
+	kind := dec.PeekKind()
+	if (kind == '{' || kind == '[') && state.exceedMaxDepth() {
+		dec.SkipValue()
+		state.Encoder.WriteToken(jsontext.Null)
+		return
+	}
+	tok, _ := dec.ReadToken()
+	state.Encoder.WriteToken(tok)
+	switch kind {
+	case '{':
+		for dec.PeekKind() != '}' {
+			nameTok, _ := dec.ReadToken()
+			name := nameTok.String()
+			conf := activeLogRule(state, name, j.getLogRule(name), j.grants)
+			if conf != nil && conf.Omit() {
+				dec.SkipValue()
+				continue
+			}
+			state.Encoder.WriteToken(jsontext.String(name))
+			if conf != nil && (dec.PeekKind() == '"' || conf.shred != nil || conf.changesStrings()) {
+				// other values are redacted as their JSON text.
+				s := readJSONText(dec)
+				if conf.shred != nil {
+					state.Encoder.WriteToken(conf.shred.token(s, state))
+					continue
+				}
+				s, _ = conf.applyString(s)
+				state.Encoder.WriteToken(jsontext.String(s))
+				continue
+			}
+			j.copyJSONValue(dec, state)
+		}
+		tok, _ = dec.ReadToken()
+		state.Encoder.WriteToken(tok)
+	case '[':
+		for dec.PeekKind() != ']' {
+			j.copyJSONValue(dec, state)
+		}
+		tok, _ = dec.ReadToken()
+		state.Encoder.WriteToken(tok)
+	}
+}
+
+// readJSONText reads the next value of dec, it returns the value of strings
+// and the compact JSON of other values.
+func readJSONText(dec *jsontext.Decoder) string {
+	if dec.PeekKind() == '"' {
+		tok, _ := dec.ReadToken()
+		return tok.String()
+	}
+	raw, _ := dec.ReadValue()
+	value := jsontext.Value(bytes.Clone(raw))
+	value.Compact()
+	return string(value)
+}
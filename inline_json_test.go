@@ -0,0 +1,69 @@
+package logjson
+
+import (
+	stdjson "encoding/json"
+	"testing"
+
+	"github.com/go-json-experiment/json/jsontext"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogJson_InlineJSON(t *testing.T) {
+	type Abc struct {
+		Raw     stdjson.RawMessage
+		Value   jsontext.Value
+		Payload string
+		Tagged  string `log:"json"`
+		Bytes   []byte `log:"json"`
+		Invalid string `log:"json"`
+		Number  string
+	}
+	abc := Abc{
+		Raw:     stdjson.RawMessage(`{"password":"secret","phone":"13812345678","list":[{"password":"x"}]}`),
+		Value:   jsontext.Value(`[1, 2.50, "a"]`),
+		Payload: `{"user":"abc","password":"secret"}`,
+		Tagged:  `"text"`,
+		Bytes:   []byte(`{"a":{"b":null}}`),
+		Invalid: `{"a":`,
+		Number:  `123`,
+	}
+	j := NewLogJson()
+	j.AddLogRule("password", LogRuleOmit())
+	j.AddLogRule("phone", LogRuleMask(3, 2))
+	require.Equal(t, `{"Raw":"eyJwYXNzd29yZCI6InNlY3JldCIsInBob25lIjoiMTM4MTIzNDU2NzgiLCJsaXN0IjpbeyJwYXNzd29yZCI6IngifV19","Value":"WzEsIDIuNTAsICJhIl0","Payload":"{\"user\":\"abc\",\"password\":\"secret\"}","Tagged":"text","Bytes":{"a":{"b":null}},"Invalid":"{\"a\":","Number":"123"}`,
+		string(j.Marshal(abc)))
+	j.SetInlineJSON(true)
+	require.Equal(t, `{"Raw":{"phone":"138******78","list":[{}]},"Value":[1,2.50,"a"],"Payload":{"user":"abc"},"Tagged":"text","Bytes":{"a":{"b":null}},"Invalid":"{\"a\":","Number":"123"}`,
+		string(j.Marshal(abc)))
+	require.Equal(t, `{"Raw":{"password":"secret","phone":"13812345678","list":[{"password":"x"}]},"Value":[1,2.50,"a"],"Payload":{"user":"abc","password":"secret"},"Tagged":"\"text\"","Bytes":"eyJhIjp7ImIiOm51bGx9fQ","Invalid":"{\"a\":","Number":"123"}`,
+		string(j.MarshalWith(abc, WithoutLogRules())))
+	require.Equal(t, `{"Raw":{"phone":"138******78","list":[null]},"Value":[1,2.50,"a"],"Payload":{"user":"abc"},"Tagged":"text","Bytes":{"a":{"b":null}},"Invalid":"{\"a\":","Number":"123"}`,
+		string(j.MarshalWith(abc, WithMaxDepth(3))))
+}
+
+func TestLogJson_InlineJSONNonStringRules(t *testing.T) {
+	j := NewLogJson()
+	j.SetInlineJSON(true)
+	j.AddLogRule("card", LogRuleMd5())
+	j.AddLogRule("phones", LogRuleMask(2, 1))
+	j.AddLogRule("pin", LogRuleMask(0, 0))
+	j.AddLogRule("level", LogRuleEnum(EnumName))
+	raw := stdjson.RawMessage(`{"card":6222021234567890,"phones":[ 1381234, 1391234 ],"pin":{"a":1},"level":3}`)
+	require.Equal(t, `{"card":"16;`+md5LogString("6222021234567890")[3:]+`","phones":"[1**************]","pin":"*******","level":3}`,
+		string(j.Marshal(raw)))
+}
+
+type testInlineJSONMarshaler string
+
+func (m testInlineJSONMarshaler) MarshalLogJSONState(state *EncoderState) {
+	NewStringRule("json").WriteInlineJSON(state, string(m))
+}
+
+func TestStringRule_WriteInlineJSON(t *testing.T) {
+	j := NewLogJson()
+	j.AddLogRule("password", LogRuleOmit())
+	m := testInlineJSONMarshaler(`{"user":"abc","password":"secret"}`)
+	require.Equal(t, `{"user":"abc"}`, string(j.Marshal(m)))
+	require.Equal(t, `{"user":"abc","password":"secret"}`, string(j.MarshalWith(m, WithoutLogRules())))
+	require.Equal(t, `{"user":"abc","password":"secret"}`, string(NewLogJson().Marshal(m)))
+}
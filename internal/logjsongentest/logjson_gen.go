@@ -0,0 +1,125 @@
+// Code generated by logjsongen. DO NOT EDIT.
+
+package logjsongentest
+
+import (
+	"github.com/ethanvc/logjson"
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+func (x *Order) MarshalLogJSONState(state *logjson.EncoderState) {
+	if x == nil {
+		state.WriteToken(jsontext.Null)
+		return
+	}
+	if !state.UseGeneratedCode() {
+		state.MarshalReflect(x)
+		return
+	}
+	enc := state.Encoder
+	enc.WriteToken(jsontext.ObjectStart)
+	enc.WriteToken(jsontext.String("id"))
+	state.WriteInt(x.Id)
+	enc.WriteToken(jsontext.String("status"))
+	state.MarshalValue(x.Status)
+	enc.WriteToken(jsontext.String("previous"))
+	logjsonRule_Order_Previous.WriteValue(state, x.Previous)
+	if len(x.Note) != 0 {
+		enc.WriteToken(jsontext.String("note"))
+		enc.WriteToken(jsontext.String(x.Note))
+	}
+	enc.WriteToken(jsontext.String("paid"))
+	enc.WriteToken(jsontext.Bool(x.Paid))
+	enc.WriteToken(jsontext.String("amount"))
+	state.WriteFloat(x.Amount, 64)
+	enc.WriteToken(jsontext.String("buyer"))
+	state.MarshalValue(x.Buyer)
+	enc.WriteToken(jsontext.String("items"))
+	state.MarshalValue(x.Items)
+	if len(x.Tags) != 0 {
+		enc.WriteToken(jsontext.String("tags"))
+		state.MarshalValue(x.Tags)
+	}
+	enc.WriteToken(jsontext.String("created_at"))
+	state.MarshalValue(x.CreatedAt)
+	enc.WriteToken(jsontext.String("city"))
+	enc.WriteToken(jsontext.String(x.Address.City))
+	enc.WriteToken(jsontext.String("street"))
+	if x.Address.Street == nil {
+		enc.WriteToken(jsontext.Null)
+	} else {
+		enc.WriteToken(jsontext.String(logjsonRule_Order_Street.Apply(*x.Address.Street)))
+	}
+	enc.WriteToken(jsontext.ObjectEnd)
+}
+
+func (x *Item) MarshalLogJSONState(state *logjson.EncoderState) {
+	if x == nil {
+		state.WriteToken(jsontext.Null)
+		return
+	}
+	if !state.UseGeneratedCode() {
+		state.MarshalReflect(x)
+		return
+	}
+	enc := state.Encoder
+	enc.WriteToken(jsontext.ObjectStart)
+	enc.WriteToken(jsontext.String("name"))
+	enc.WriteToken(jsontext.String(x.Name))
+	if x.Quantity != 0 {
+		enc.WriteToken(jsontext.String("quantity"))
+		state.WriteUint(uint64(x.Quantity))
+	}
+	enc.WriteToken(jsontext.String("price"))
+	state.WriteFloat(float64(x.Price), 32)
+	if x.Parent != nil {
+		enc.WriteToken(jsontext.String("parent"))
+		state.MarshalValue(x.Parent)
+	}
+	enc.WriteToken(jsontext.String("payload"))
+	enc.WriteToken(jsontext.String(logjsonRule_Item_Payload.ApplyBytes(x.Payload)))
+	enc.WriteToken(jsontext.ObjectEnd)
+}
+
+func (x *Buyer) MarshalLogJSONState(state *logjson.EncoderState) {
+	if x == nil {
+		state.WriteToken(jsontext.Null)
+		return
+	}
+	if !state.UseGeneratedCode() {
+		state.MarshalReflect(x)
+		return
+	}
+	enc := state.Encoder
+	enc.WriteToken(jsontext.ObjectStart)
+	enc.WriteToken(jsontext.String("name"))
+	enc.WriteToken(jsontext.String(x.Name))
+	enc.WriteToken(jsontext.String("phone"))
+	enc.WriteToken(jsontext.String(logjsonRule_Buyer_Phone.Apply(x.Phone)))
+	enc.WriteToken(jsontext.String("email"))
+	if x.Email == nil {
+		enc.WriteToken(jsontext.Null)
+	} else {
+		enc.WriteToken(jsontext.String(logjsonRule_Buyer_Email.Apply(*x.Email)))
+	}
+	enc.WriteToken(jsontext.String("level"))
+	if x.Level == nil {
+		enc.WriteToken(jsontext.Null)
+	} else {
+		state.WriteInt(int64(*x.Level))
+	}
+	enc.WriteToken(jsontext.String("err"))
+	state.MarshalValue(x.Err)
+	enc.WriteToken(jsontext.String("extra"))
+	logjsonRule_Buyer_Extra.WriteInlineJSON(state, x.Extra)
+	enc.WriteToken(jsontext.ObjectEnd)
+}
+
+var (
+	logjsonRule_Order_Previous = logjson.NewStringRule("enum=both")
+	logjsonRule_Order_Street   = logjson.NewStringRule("mask=2:2")
+	logjsonRule_Item_Payload   = logjson.NewStringRule("bytes=hex")
+	logjsonRule_Buyer_Phone    = logjson.NewStringRule("md5")
+	logjsonRule_Buyer_Email    = logjson.NewStringRule("sha256")
+	logjsonRule_Buyer_Extra    = logjson.NewStringRule("json")
+)
@@ -0,0 +1,57 @@
+// Package logjsongentest contains types marshaled by code generated by
+// logjsongen.
+package logjsongentest
+
+import "time"
+
+//go:generate go -C ../../cmd run ./logjsongen -type=Order,Item,Buyer ../internal/logjsongentest
+
+type Order struct {
+	Id        int64             `json:"id"`
+	Status    Status            `json:"status"`
+	Previous  *Status           `json:"previous" log:"enum=both"`
+	Note      string            `json:"note,omitempty"`
+	Paid      bool              `json:"paid"`
+	Amount    float64           `json:"amount"`
+	Buyer     *Buyer            `json:"buyer"`
+	Items     []Item            `json:"items"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	Secret    string            `json:"-" log:"omit"`
+	Address
+	internal string
+}
+
+type Status int32
+
+func (s Status) String() string {
+	switch s {
+	case 1:
+		return "CREATED"
+	case 2:
+		return "PAID"
+	}
+	return "UNKNOWN"
+}
+
+type Address struct {
+	City   string  `json:"city"`
+	Street *string `json:"street" log:"mask=2:2"`
+}
+
+type Item struct {
+	Name     string  `json:"name"`
+	Quantity uint32  `json:"quantity,omitempty"`
+	Price    float32 `json:"price"`
+	Parent   *Item   `json:"parent,omitempty"`
+	Payload  []byte  `json:"payload" log:"bytes=hex"`
+}
+
+type Buyer struct {
+	Name  string  `json:"name"`
+	Phone string  `json:"phone" log:"md5"`
+	Email *string `json:"email" log:"sha256"`
+	Level *int    `json:"level"`
+	Err   error   `json:"err"`
+	Extra string  `json:"extra" log:"json"`
+}
@@ -0,0 +1,117 @@
+package logjsongentest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethanvc/logjson"
+	"github.com/stretchr/testify/require"
+)
+
+type orderMirror Order
+
+type buyerMirror Buyer
+
+func marshalGenerated(in logjson.StateLogMarshaler) string {
+	buf := bytes.NewBuffer(nil)
+	in.MarshalLogJSONState(logjson.NewEncoderState(buf))
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+func TestGeneratedMatchesReflection(t *testing.T) {
+	street := "Main Street"
+	email := "a@b.c"
+	level := 3
+	previous := Status(1)
+	orders := []*Order{
+		{},
+		{
+			Id:        1,
+			Status:    2,
+			Previous:  &previous,
+			Note:      "note",
+			Paid:      true,
+			Amount:    12.5,
+			Buyer:     &Buyer{Name: "abc", Phone: "13812345678", Email: &email, Level: &level, Err: errors.New("err"), Extra: `{"phone":"13812345678","tags":["a"]}`},
+			Items:     []Item{{Name: "x", Quantity: 2, Price: 0.1, Parent: &Item{Name: "p"}, Payload: []byte("hi")}, {}},
+			Tags:      map[string]string{"a": "b"},
+			CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			Secret:    "secret",
+			Address:   Address{City: "city", Street: &street},
+			internal:  "internal",
+		},
+	}
+	for _, order := range orders {
+		require.Equal(t, string(logjson.NewLogJson().Marshal((*orderMirror)(order))), marshalGenerated(order))
+		require.Equal(t, string(logjson.NewLogJson().Marshal((*buyerMirror)(order.Buyer))), marshalGenerated(order.Buyer))
+	}
+	require.Equal(t, `{"name":"abc","phone":"11;f1af0bfd057c211100285ce6455b04d2","email":null,"level":null,"err":null,"extra":""}`,
+		marshalGenerated(&Buyer{Name: "abc", Phone: "abc123xxxxx"}))
+}
+
+func TestGeneratedEnum(t *testing.T) {
+	previous := Status(2)
+	require.Contains(t, marshalGenerated(&Order{Status: 1, Previous: &previous}), `"status":1,"previous":{"code":2,"name":"PAID"}`)
+}
+
+func TestGeneratedLogJsonRules(t *testing.T) {
+	email := "a@b.c"
+	order := &Order{
+		Id:    1 << 60,
+		Buyer: &Buyer{Name: "abc", Phone: "13812345678", Email: &email},
+		Items: []Item{{Name: "x", Price: 0.5}},
+	}
+	j := logjson.NewLogJson()
+	j.SetLargeIntAsString(true)
+	j.SetFloatDecimals(2)
+	j.AddLogRule("name", logjson.LogRuleOmit())
+	j.AddTypeRule(reflect.TypeFor[Item](), logjson.LogRuleOmit())
+	expected := `{"id":"1152921504606846976","status":0,"previous":null,"paid":false,"amount":0.00,` +
+		`"buyer":{"phone":"11;4c0115d39ddea1eba5a7cdf8edfc18a9","email":"5;` + sha256Hex(email) + `","level":null,"err":null,"extra":""},` +
+		`"items":[null],"created_at":{},"city":"","street":null}`
+	require.Equal(t, expected, string(j.Marshal(order)))
+	require.Equal(t, string(j.Marshal((*orderMirror)(order))), string(j.Marshal(order)))
+	for _, opt := range []logjson.MarshalOption{
+		logjson.WithoutLogRules(),
+		logjson.WithLogRule("phone", logjson.LogRuleKeep()),
+		logjson.WithMaxDepth(2),
+	} {
+		require.Equal(t, string(j.MarshalWith((*orderMirror)(order), opt)), string(j.MarshalWith(order, opt)))
+	}
+	view := j.View(logjson.NewLogPolicy().IgnoreFieldRules())
+	require.Contains(t, string(view.Marshal(order)), `"phone":"13812345678"`)
+	require.Equal(t, string(view.Marshal((*orderMirror)(order))), string(view.Marshal(order)))
+}
+
+func TestGeneratedInlineJSON(t *testing.T) {
+	order := &Order{
+		Note:  `{"a":1}`,
+		Buyer: &Buyer{Name: `["x"]`, Extra: `{"b":2}`},
+		Items: []Item{{Name: "plain"}},
+		Tags:  map[string]string{"t": `{"c":3}`},
+	}
+	j := logjson.NewLogJson()
+	j.SetInlineJSON(true)
+	require.Contains(t, string(j.Marshal(order)), `"note":{"a":1}`)
+	require.Equal(t, string(j.Marshal((*orderMirror)(order))), string(j.Marshal(order)))
+	require.Equal(t, string(j.Marshal((*buyerMirror)(order.Buyer))), string(j.Marshal(order.Buyer)))
+}
+
+func TestGeneratedCycle(t *testing.T) {
+	item := &Item{Name: "a"}
+	item.Parent = item
+	j := logjson.NewLogJson()
+	j.SetCycleDetectionDepth(0)
+	require.Equal(t, `{"name":"a","price":0,"parent":{"$ref":"$"},"payload":""}`, string(j.Marshal(item)))
+	require.Contains(t, string(logjson.NewLogJson().Marshal(item)), `{"$ref":"$.parent.parent`)
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
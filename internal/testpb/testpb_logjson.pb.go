@@ -0,0 +1,219 @@
+// Code generated by protoc-gen-logjson. DO NOT EDIT.
+// source: internal/testpb/testpb.proto
+
+package testpb
+
+import (
+	base64 "encoding/base64"
+	logjson "github.com/ethanvc/logjson"
+	jsontext "github.com/go-json-experiment/json/jsontext"
+	proto "google.golang.org/protobuf/proto"
+	math "math"
+	sort "sort"
+	strconv "strconv"
+)
+
+func (x *Order) MarshalLogJSONState(state *logjson.EncoderState) {
+	if x == nil {
+		state.WriteToken(jsontext.Null)
+		return
+	}
+	if !state.UseGeneratedProtoCode(false) {
+		state.MarshalReflect(x)
+		return
+	}
+	enc := state.Encoder
+	enc.WriteToken(jsontext.ObjectStart)
+	if x.Id != 0 {
+		enc.WriteToken(jsontext.String("id"))
+		enc.WriteToken(jsontext.String(strconv.FormatInt(x.Id, 10)))
+	}
+	if x.Name != "" {
+		enc.WriteToken(jsontext.String("name"))
+		enc.WriteToken(jsontext.String(logjsonRule_Order_Name.Apply(x.Name)))
+	}
+	if x.Phone != nil {
+		enc.WriteToken(jsontext.String("phone"))
+		enc.WriteToken(jsontext.String(logjsonRule_Order_Phone.Apply(*x.Phone)))
+	}
+	if math.Float32bits(x.Ratio) != 0 {
+		enc.WriteToken(jsontext.String("ratio"))
+		logjson.WriteFloat(enc, float64(x.Ratio), 32)
+	}
+	if math.Float64bits(x.Amount) != 0 {
+		enc.WriteToken(jsontext.String("amount"))
+		logjson.WriteFloat(enc, x.Amount, 64)
+	}
+	if len(x.Data) > 0 {
+		enc.WriteToken(jsontext.String("data"))
+		enc.WriteToken(jsontext.String(base64.StdEncoding.EncodeToString(x.Data)))
+	}
+	if x.Paid {
+		enc.WriteToken(jsontext.String("paid"))
+		enc.WriteToken(jsontext.Bool(x.Paid))
+	}
+	if x.Big != 0 {
+		enc.WriteToken(jsontext.String("big"))
+		enc.WriteToken(jsontext.String(strconv.FormatUint(x.Big, 10)))
+	}
+	if x.Status != 0 && x.Status != Status_STATUS_FRAUD {
+		enc.WriteToken(jsontext.String("status"))
+		switch x.Status {
+		case Status_STATUS_UNKNOWN:
+			enc.WriteToken(jsontext.String("STATUS_UNKNOWN"))
+		case Status_STATUS_PAID:
+			enc.WriteToken(jsontext.String("STATUS_PAID"))
+		default:
+			enc.WriteToken(jsontext.Int(int64(x.Status)))
+		}
+	}
+	if len(x.Items) > 0 {
+		enc.WriteToken(jsontext.String("items"))
+		enc.WriteToken(jsontext.ArrayStart)
+		for _, v := range x.Items {
+			state.MarshalValue(v)
+		}
+		enc.WriteToken(jsontext.ArrayEnd)
+	}
+	if len(x.Counts) > 0 {
+		enc.WriteToken(jsontext.String("counts"))
+		keys1 := make([]string, 0, len(x.Counts))
+		for k := range x.Counts {
+			keys1 = append(keys1, k)
+		}
+		sort.Slice(keys1, func(a, b int) bool { return keys1[a] < keys1[b] })
+		enc.WriteToken(jsontext.ObjectStart)
+		for _, k := range keys1 {
+			enc.WriteToken(jsontext.String(k))
+			enc.WriteToken(jsontext.Int(int64(x.Counts[k])))
+		}
+		enc.WriteToken(jsontext.ObjectEnd)
+	}
+	if len(x.ItemsById) > 0 {
+		enc.WriteToken(jsontext.String("items_by_id"))
+		keys2 := make([]int64, 0, len(x.ItemsById))
+		for k := range x.ItemsById {
+			keys2 = append(keys2, k)
+		}
+		sort.Slice(keys2, func(a, b int) bool { return keys2[a] < keys2[b] })
+		enc.WriteToken(jsontext.ObjectStart)
+		for _, k := range keys2 {
+			enc.WriteToken(jsontext.String(strconv.FormatInt(int64(k), 10)))
+			state.MarshalValue(x.ItemsById[k])
+		}
+		enc.WriteToken(jsontext.ObjectEnd)
+	}
+	if ov, ok := x.Payload.(*Order_Text); ok {
+		enc.WriteToken(jsontext.String("text"))
+		enc.WriteToken(jsontext.String(ov.Text))
+	}
+	if ov, ok := x.Payload.(*Order_Item); ok {
+		enc.WriteToken(jsontext.String("item"))
+		state.MarshalValue(ov.Item)
+	}
+	if x.CreatedAt != nil {
+		enc.WriteToken(jsontext.String("created_at"))
+		state.MarshalValue(x.CreatedAt)
+	}
+	if x.Extra != nil {
+		enc.WriteToken(jsontext.String("extra"))
+		state.MarshalValue(x.Extra)
+	}
+	if x.Secret != nil {
+		enc.WriteToken(jsontext.String("secret"))
+		state.MarshalValue(x.Secret)
+	}
+	if len(x.Statuses) > 0 {
+		enc.WriteToken(jsontext.String("statuses"))
+		enc.WriteToken(jsontext.ArrayStart)
+		for _, v := range x.Statuses {
+			if v == Status_STATUS_FRAUD {
+				continue
+			}
+			switch v {
+			case Status_STATUS_UNKNOWN:
+				enc.WriteToken(jsontext.String("STATUS_UNKNOWN"))
+			case Status_STATUS_PAID:
+				enc.WriteToken(jsontext.String("STATUS_PAID"))
+			default:
+				enc.WriteToken(jsontext.Int(int64(v)))
+			}
+		}
+		enc.WriteToken(jsontext.ArrayEnd)
+	}
+	if len(x.Flags) > 0 {
+		enc.WriteToken(jsontext.String("flags"))
+		keys3 := make([]bool, 0, len(x.Flags))
+		for k := range x.Flags {
+			keys3 = append(keys3, k)
+		}
+		sort.Slice(keys3, func(a, b int) bool { return !keys3[a] && keys3[b] })
+		enc.WriteToken(jsontext.ObjectStart)
+		for _, k := range keys3 {
+			enc.WriteToken(jsontext.String(strconv.FormatBool(k)))
+			enc.WriteToken(jsontext.String(x.Flags[k]))
+		}
+		enc.WriteToken(jsontext.ObjectEnd)
+	}
+	enc.WriteToken(jsontext.ObjectEnd)
+}
+
+var (
+	logjsonRule_Order_Name  = logjson.NewStringRule("md5")
+	logjsonRule_Order_Phone = logjson.NewStringRule("mask=3:2")
+)
+
+func (x *Item) MarshalLogJSONState(state *logjson.EncoderState) {
+	if x == nil {
+		state.WriteToken(jsontext.Null)
+		return
+	}
+	if !state.UseGeneratedProtoCode(false) {
+		state.MarshalReflect(x)
+		return
+	}
+	enc := state.Encoder
+	enc.WriteToken(jsontext.ObjectStart)
+	if x.Sku != "" {
+		enc.WriteToken(jsontext.String("sku"))
+		enc.WriteToken(jsontext.String(logjsonRule_Item_Sku.Apply(x.Sku)))
+	}
+	if x.Count != 0 {
+		enc.WriteToken(jsontext.String("count"))
+		enc.WriteToken(jsontext.Uint(uint64(x.Count)))
+	}
+	enc.WriteToken(jsontext.ObjectEnd)
+}
+
+var (
+	logjsonRule_Item_Sku = logjson.NewStringRule("sha256")
+)
+
+func (x *Secret) MarshalLogJSONState(state *logjson.EncoderState) {
+	if x == nil {
+		state.WriteToken(jsontext.Null)
+		return
+	}
+	if !state.UseGeneratedProtoCode(false) {
+		state.MarshalReflect(x)
+		return
+	}
+	buf, _ := proto.MarshalOptions{Deterministic: true, AllowPartial: true}.Marshal(x)
+	state.WriteToken(jsontext.String(logjsonRule_Secret.Apply(string(buf))))
+}
+
+var (
+	logjsonRule_Secret = logjson.NewStringRule("md5")
+)
+
+func (x *Hidden) MarshalLogJSONState(state *logjson.EncoderState) {
+	if x == nil {
+		state.WriteToken(jsontext.Null)
+		return
+	}
+	if !state.UseGeneratedProtoCode(false) {
+		state.MarshalReflect(x)
+		return
+	}
+	state.WriteToken(jsontext.Null)
+}
@@ -2,11 +2,15 @@ package logjson
 
 import (
 	"bytes"
+	"cmp"
+	"context"
 	"crypto/md5"
-	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"maps"
+	"math"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -21,6 +25,44 @@ type LogJson struct {
 	handlerItems sync.Map
 	mux          sync.Mutex
 	logRules     map[string]*logRuleConf
+	// typeRules are added by AddTypeRule.
+	typeRules map[reflect.Type]*logRuleConf
+	// decimalTypes are added by AddDecimalType.
+	decimalTypes map[reflect.Type]func(v reflect.Value) string
+	types        *typeAnalyzer
+	grants       *grantRegistry
+	// ignoreFieldRules makes struct fields ignore log tags and proto options.
+	ignoreFieldRules bool
+	protoItems       sync.Map
+	// mapKeyFuncs caches the funcs converting the dynamic types of interface
+	// map keys to strings.
+	mapKeyFuncs sync.Map
+	opts        logJsonOptions
+}
+
+// logJsonOptions are the output options of a LogJson, views inherit them.
+type logJsonOptions struct {
+	protoJsonName bool
+	sortMapKeys   bool
+	// strictMapKeys and mapKeyStringer are set by SetStrictMapKeys and
+	// SetMapKeyStringer.
+	strictMapKeys  bool
+	mapKeyStringer bool
+	// describeOpaqueKinds is set by SetDescribeOpaqueKinds.
+	describeOpaqueKinds bool
+	bytesFormat         BytesFormat
+	inlineJSON          bool
+	enumFormat          EnumFormat
+	// largeIntAsString, floatDecimals and fixedFloats are set by
+	// SetLargeIntAsString and SetFloatDecimals.
+	largeIntAsString bool
+	floatDecimals    int
+	fixedFloats      bool
+	// cycleDepth and cycleDepthSet are set by SetCycleDetectionDepth.
+	cycleDepth    int
+	cycleDepthSet bool
+	// dedupBudget is set by SetDedupPointers.
+	dedupBudget int
 }
 
 var defaultLogJson = NewLogJson()
@@ -32,30 +74,132 @@ func DefaultLogJson() *LogJson {
 func NewLogJson() *LogJson {
 	return &LogJson{
 		logRules: make(map[string]*logRuleConf),
+		types:    &typeAnalyzer{},
+		grants:   newGrantRegistry(),
+	}
+}
+
+// View returns a LogJson which shares type analysis, grants and the grant
+// auditor with j but applies the rules of policy instead of the rules added to
+// j by AddLogRule.
+func (j *LogJson) View(policy *LogPolicy) *LogJson {
+	view := &LogJson{
+		logRules: make(map[string]*logRuleConf),
+		types:    j.types,
+		grants:   j.grants,
+		opts:     j.getOptions(),
+	}
+	j.mux.Lock()
+	view.decimalTypes = maps.Clone(j.decimalTypes)
+	j.mux.Unlock()
+	if policy == nil {
+		return view
 	}
+	for key, rule := range policy.rules {
+		view.logRules[key] = newLogRuleConf(rule)
+	}
+	view.ignoreFieldRules = policy.ignoreFieldRules
+	return view
 }
 
 func (j *LogJson) AddLogRule(key string, rule LogRule) {
 	conf := newLogRuleConf(rule)
 	j.mux.Lock()
-	defer j.mux.Unlock()
 	j.logRules[key] = conf
+	j.mux.Unlock()
+	j.resetHandlerItems()
 }
 
-func (j *LogJson) Marshal(in any) []byte {
-	var encoder *EncoderState
-	var buf *bytes.Buffer
-	encoderAny := encoderStatePool.Get()
-	if encoderAny == nil {
-		buf = bytes.NewBuffer(nil)
-		encoder = NewEncoderState(buf)
-	} else {
-		encoder = encoderAny.(*EncoderState)
-		buf = encoder.GetWriter().(*bytes.Buffer)
-		buf.Reset()
-		encoder.Reset(buf)
+// AddTypeRule applies rule to the values of type t wherever they appear,
+// fields of type t or *t without rules of their own use it as their rule, so
+// they are omitted by LogRuleOmit. The fields promoted from an embedded t are
+// omitted with it. Values which are not fields are written as null when
+// omitted.
+func (j *LogJson) AddTypeRule(t reflect.Type, rule LogRule) {
+	conf := newLogRuleConf(rule)
+	j.mux.Lock()
+	if j.typeRules == nil {
+		j.typeRules = make(map[reflect.Type]*logRuleConf)
 	}
+	j.typeRules[t] = conf
+	j.mux.Unlock()
+	j.resetHandlerItems()
+}
+
+// TypeRuleKey returns the key of the rule of AddTypeRule for type t in
+// per-call rules of WithLogRule and in grants, such as "type:main.Address". It
+// does not collide with field names.
+func TypeRuleKey(t reflect.Type) string {
+	return "type:" + t.String()
+}
+
+func (j *LogJson) getTypeRule(t reflect.Type) *logRuleConf {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+	return j.typeRules[t]
+}
+
+// SetProtoJsonName makes proto messages use the json_name of fields like
+// protojson does by default, instead of the field names in the proto file.
+func (j *LogJson) SetProtoJsonName(use bool) {
+	j.updateOptions(func(opts *logJsonOptions) {
+		opts.protoJsonName = use
+	})
+}
+
+// SetSortMapKeys makes maps written with their keys sorted, so the output is
+// deterministic. Keys are sorted as strings, integer keys are sorted by their
+// values.
+func (j *LogJson) SetSortMapKeys(sort bool) {
+	j.updateOptions(func(opts *logJsonOptions) {
+		opts.sortMapKeys = sort
+	})
+}
+
+func (j *LogJson) getOptions() logJsonOptions {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+	return j.opts
+}
+
+// updateOptions changes the options and drops the handlers created with the
+// previous options.
+func (j *LogJson) updateOptions(update func(opts *logJsonOptions)) {
+	j.mux.Lock()
+	update(&j.opts)
+	j.mux.Unlock()
+	j.resetHandlerItems()
+}
+
+// resetHandlerItems drops the handlers created before a change of the
+// options or the type rules.
+func (j *LogJson) resetHandlerItems() {
+	j.handlerItems.Range(func(key, value any) bool {
+		j.handlerItems.Delete(key)
+		return true
+	})
+	j.protoItems.Range(func(key, value any) bool {
+		j.protoItems.Delete(key)
+		return true
+	})
+	j.mapKeyFuncs.Range(func(key, value any) bool {
+		j.mapKeyFuncs.Delete(key)
+		return true
+	})
+}
+
+func (j *LogJson) Marshal(in any) []byte {
+	return j.MarshalWith(in)
+}
+
+func (j *LogJson) MarshalContext(ctx context.Context, in any) []byte {
+	return j.MarshalWith(in, WithContext(ctx))
+}
+
+func (j *LogJson) MarshalWith(in any, opts ...MarshalOption) []byte {
+	encoder, buf := getPooledEncoderState()
 	defer encoderStatePool.Put(encoder)
+	encoder.SetOptions(opts...)
 	j.MarshalWithState(in, encoder)
 	return removeNewline(buf.Bytes())
 }
@@ -66,7 +210,10 @@ func (j *LogJson) MarshalWithState(in any, encoder *EncoderState) {
 		encoder.Encoder.WriteToken(jsontext.Null)
 		return
 	}
+	prevLogJson := encoder.logJson
+	encoder.logJson = j
 	j.getHandlerItem(v.Type()).marshal(v, encoder)
+	encoder.logJson = prevLogJson
 }
 
 func (j *LogJson) getLogRule(key string) *logRuleConf {
@@ -77,29 +224,112 @@ func (j *LogJson) getLogRule(key string) *logRuleConf {
 
 var errorIntType = reflect.TypeFor[error]()
 var logMarshalerIntType = reflect.TypeFor[LogMarshaler]()
+var stateLogMarshalerIntType = reflect.TypeFor[StateLogMarshaler]()
 var marshalerV2IntType = reflect.TypeFor[json.MarshalerV2]()
+var protoMessageIntType = reflect.TypeFor[proto.Message]()
+var decimalMarshalerIntType = reflect.TypeFor[DecimalMarshaler]()
+
+// marshalerIntTypes are the interfaces preferred to the kinds of types, in the
+// order of priority.
+var marshalerIntTypes = []reflect.Type{
+	stateLogMarshalerIntType,
+	logMarshalerIntType,
+	protoMessageIntType,
+	marshalerV2IntType,
+	errorIntType,
+	decimalMarshalerIntType,
+}
+
+// marshalerIntIndex returns the index of the first interface in
+// marshalerIntTypes implemented by t, or len(marshalerIntTypes).
+func marshalerIntIndex(t reflect.Type) int {
+	for i, intType := range marshalerIntTypes {
+		if t.Implements(intType) {
+			return i
+		}
+	}
+	return len(marshalerIntTypes)
+}
 
 func (j *LogJson) getHandlerItemInternal(t reflect.Type) *handlerItem {
-	if t.Implements(logMarshalerIntType) {
-		return j.makeLogMarshalerHandlerItem()
+	if conf := j.getTypeRule(t); conf != nil {
+		return j.makeTypeRuleHandlerItem(t, conf, j.makeHandlerItem(t))
 	}
-	if t.Implements(marshalerV2IntType) {
-		return j.makeMarshalerV2HandlerItem()
+	return j.makeHandlerItem(t)
+}
+
+// makeTypeRuleHandlerItem creates the handler of t applying the rule added by
+// AddTypeRule, rawHandlerItem writes t without the rule.
+func (j *LogJson) makeTypeRuleHandlerItem(t reflect.Type, conf *logRuleConf, rawHandlerItem *handlerItem) *handlerItem {
+	name := TypeRuleKey(t)
+	ruleHandlerItem := conf.getHandlerItem(t, rawHandlerItem)
+	return &handlerItem{
+		marshal: func(v reflect.Value, state *EncoderState) {
+			item := ruleHandlerItem
+			if activeConf := activeLogRule(state, name, conf, j.grants); activeConf != conf {
+				item = activeConf.getHandlerItem(t, rawHandlerItem)
+			}
+			if item == nil {
+				state.Encoder.WriteToken(jsontext.Null)
+				return
+			}
+			item.marshal(v, state)
+		},
 	}
-	if t.Implements(errorIntType) {
+}
+
+func (j *LogJson) makeHandlerItem(t reflect.Type) *handlerItem {
+	if text := j.getDecimalText(t); text != nil {
+		return j.makeDecimalHandlerItem(text)
+	}
+	if t.Kind() != reflect.Pointer && j.getDecimalText(reflect.PointerTo(t)) != nil {
+		return j.makeAddrHandlerItem(t)
+	}
+	index := marshalerIntIndex(t)
+	if t.Kind() != reflect.Pointer && t.Kind() != reflect.Interface && marshalerIntIndex(reflect.PointerTo(t)) < index {
+		return j.makeAddrHandlerItem(t)
+	}
+	if index == len(marshalerIntTypes) && isRawJSONType(t) && j.getOptions().inlineJSON {
+		return &handlerItem{marshal: createInlineJSONMarshal(t, false)}
+	}
+	if format := j.getOptions().enumFormat; format != EnumNumber && index == len(marshalerIntTypes) {
+		if marshal := createEnumMarshal(t, format); marshal != nil {
+			return &handlerItem{marshal: marshal}
+		}
+	}
+	switch index {
+	case 0:
+		return j.withCycleDetection(t, j.makeStateLogMarshalerHandlerItem())
+	case 1:
+		return j.withCycleDetection(t, j.makeLogMarshalerHandlerItem())
+	case 2:
+		return j.makeProtoMessageHandlerItem()
+	case 3:
+		return j.makeMarshalerV2HandlerItem()
+	case 4:
 		return j.makeErrorHandlerItem()
+	case 5:
+		return j.makeDecimalHandlerItem(func(v reflect.Value) string {
+			return v.Interface().(DecimalMarshaler).LogDecimal()
+		})
 	}
+	return j.makeKindHandlerItem(t)
+}
+
+// makeKindHandlerItem creates the handler of t by its kind, ignoring the
+// marshaler methods of t.
+func (j *LogJson) makeKindHandlerItem(t reflect.Type) *handlerItem {
 	switch t.Kind() {
 	case reflect.Bool:
 		return j.makeBoolHandlerItem()
 	case reflect.String:
-		return j.makeStringHandlerItem()
+		return j.makeStringHandlerItem(t)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return j.makeIntHandlerItem(t)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		return j.makeUintHandlerItem(t)
 	case reflect.Float32, reflect.Float64:
-		return j.makeDoubleHandlerItem()
+		return j.makeFloatHandlerItem(t)
 	case reflect.Map:
 		return j.makeMapHandlerItem(t)
 	case reflect.Struct:
@@ -113,6 +343,11 @@ func (j *LogJson) getHandlerItemInternal(t reflect.Type) *handlerItem {
 	case reflect.Interface:
 		return j.makeInterfaceHandlerItem(t)
 	}
+	if j.getOptions().describeOpaqueKinds {
+		if item := makeOpaqueKindHandlerItem(t); item != nil {
+			return item
+		}
+	}
 	return &handlerItem{
 		marshal: func(v reflect.Value, state *EncoderState) {
 			state.Encoder.WriteToken(jsontext.Null)
@@ -138,6 +373,95 @@ func (j *LogJson) makeLogMarshalerHandlerItem() *handlerItem {
 	}
 }
 
+// makeAddrHandlerItem creates the handler of t whose methods with pointer
+// receivers are preferred, values which are not addressable, such as map
+// values, are copied to a temporary.
+func (j *LogJson) makeAddrHandlerItem(t reflect.Type) *handlerItem {
+	var once sync.Once
+	var ptrHandlerItem *handlerItem
+	init := func() {
+		ptrHandlerItem = j.getHandlerItem(reflect.PointerTo(t))
+	}
+	return &handlerItem{
+		marshal: func(v reflect.Value, state *EncoderState) {
+			once.Do(init)
+			if !v.CanAddr() {
+				tmp := reflect.New(t).Elem()
+				tmp.Set(v)
+				v = tmp
+			}
+			ptrHandlerItem.marshal(v.Addr(), state)
+		},
+	}
+}
+
+// withCycleDetection adds the detection of cycles to item, the handler of the
+// marshaler methods of t, when t is a pointer type. Marshalers writing their
+// sub-values by EncoderState.MarshalValue, such as the code generated by
+// logjsongen, may nest pointers like the handlers of the kinds do.
+func (j *LogJson) withCycleDetection(t reflect.Type, item *handlerItem) *handlerItem {
+	if t.Kind() != reflect.Pointer {
+		return item
+	}
+	cycleDepth := j.getOptions().cycleDetectionDepth()
+	return &handlerItem{
+		marshal: func(v reflect.Value, state *EncoderState) {
+			if !v.IsNil() && state.Encoder.StackDepth() >= cycleDepth {
+				if !state.enterPointer(v) {
+					state.writeCycleRef(v)
+					return
+				}
+				defer state.leavePointer(v)
+			}
+			item.marshal(v, state)
+		},
+	}
+}
+
+// reflectHandlerKey is the key of the handlers of MarshalReflect in
+// handlerItems.
+type reflectHandlerKey struct {
+	t reflect.Type
+}
+
+func (j *LogJson) getReflectHandlerItem(t reflect.Type) *handlerItem {
+	key := reflectHandlerKey{t}
+	if tmp, ok := j.handlerItems.Load(key); ok {
+		return tmp.(*handlerItem)
+	}
+	handler := j.makeReflectHandlerItem(t)
+	if existHandler, loaded := j.handlerItems.LoadOrStore(key, handler); loaded {
+		return existHandler.(*handlerItem)
+	}
+	return handler
+}
+
+// makeReflectHandlerItem creates the handler of MarshalReflect for t, which
+// ignores the marshaler methods of t and of the values t points to, proto
+// messages are written by their descriptors. The cycles of t are detected by
+// the handler of the marshaler calling MarshalReflect, see withCycleDetection.
+func (j *LogJson) makeReflectHandlerItem(t reflect.Type) *handlerItem {
+	if t.Implements(protoMessageIntType) {
+		return j.makeReflectProtoMessageHandlerItem()
+	}
+	if t.Kind() == reflect.Pointer {
+		return j.makePointerHandlerItemWith(t, j.getReflectHandlerItem, math.MaxInt)
+	}
+	if conf := j.getTypeRule(t); conf != nil {
+		return j.makeTypeRuleHandlerItem(t, conf, j.makeKindHandlerItem(t))
+	}
+	return j.makeKindHandlerItem(t)
+}
+
+func (j *LogJson) makeStateLogMarshalerHandlerItem() *handlerItem {
+	return &handlerItem{
+		marshal: func(v reflect.Value, state *EncoderState) {
+			realInt, _ := v.Interface().(StateLogMarshaler)
+			realInt.MarshalLogJSONState(state)
+		},
+	}
+}
+
 func (j *LogJson) getHandlerItem(t reflect.Type) *handlerItem {
 	if tmp, ok := j.handlerItems.Load(t); ok {
 		return tmp.(*handlerItem)
@@ -184,6 +508,10 @@ func (j *LogJson) makeArrayHandlerItem(t reflect.Type) *handlerItem {
 	}
 	n := t.Len()
 	item.marshal = func(v reflect.Value, state *EncoderState) {
+		if state.exceedMaxDepth() {
+			state.Encoder.WriteToken(jsontext.Null)
+			return
+		}
 		once.Do(init)
 		state.Encoder.WriteToken(jsontext.ArrayStart)
 		for i := 0; i < n; i++ {
@@ -196,7 +524,8 @@ func (j *LogJson) makeArrayHandlerItem(t reflect.Type) *handlerItem {
 
 func (j *LogJson) makeMapHandlerItem(t reflect.Type) *handlerItem {
 	item := &handlerItem{}
-	keyStringify, ok := generateMarshalToStringFunc(t.Key())
+	keyStringify, ok := j.makeMapKeyStringify(t.Key())
+	sortKeys := j.getOptions().sortMapKeys
 	if !ok {
 		return &handlerItem{
 			marshal: func(v reflect.Value, state *EncoderState) {
@@ -209,48 +538,118 @@ func (j *LogJson) makeMapHandlerItem(t reflect.Type) *handlerItem {
 	init := func() {
 		valueHandlerItem = j.getHandlerItem(t.Elem())
 	}
+	cycleDepth := j.getOptions().cycleDetectionDepth()
+	dedupLimit := j.getOptions().dedupLimit()
+	uniqueKeys := j.mapKeysUnique(t.Key())
 	item.marshal = func(v reflect.Value, state *EncoderState) {
-		if v.IsNil() {
+		if v.IsNil() || state.exceedMaxDepth() {
 			state.Encoder.WriteToken(jsontext.Null)
 			return
 		}
-		if state.Encoder.StackDepth() > startDetectingCyclesAfter {
+		id := 0
+		if dedupLimit > 0 && v.Len() > 0 {
+			key := valueId{t, v.UnsafePointer(), 0}
+			if state.writeDedupRef(key) {
+				return
+			}
+			if len(state.dedupIds) < dedupLimit && !mapHasDedupName(v, keyStringify, uniqueKeys, state) {
+				id = state.addDedupId(key, dedupLimit)
+			}
+		}
+		if state.Encoder.StackDepth() >= cycleDepth {
 			if !state.enterPointer(v) {
-				state.Encoder.WriteToken(jsontext.Null)
+				state.writeCycleRef(v)
 				return
 			}
 			defer state.leavePointer(v)
 		}
 		once.Do(init)
 		state.Encoder.WriteToken(jsontext.ObjectStart)
-		for iter := v.MapRange(); iter.Next(); {
-			tmp := keyStringify(iter.Key())
-			state.Encoder.WriteToken(jsontext.String(tmp))
-			valueHandlerItem.marshal(iter.Value(), state)
+		if id != 0 {
+			state.writeDedupId(id)
+		}
+		var names mapKeyNames
+		if !uniqueKeys {
+			names = make(mapKeyNames, v.Len())
+		}
+		if sortKeys {
+			marshalSortedMapEntries(v, keyStringify, names, valueHandlerItem, state)
+		} else {
+			for iter := v.MapRange(); iter.Next(); {
+				writeMapEntry(keyStringify(iter.Key(), state), names, iter.Value(), valueHandlerItem, state)
+			}
 		}
 		state.Encoder.WriteToken(jsontext.ObjectEnd)
 	}
 	return item
 }
 
-func (j *LogJson) makeBoolHandlerItem() *handlerItem {
-	item := &handlerItem{}
-	item.marshal = func(v reflect.Value, state *EncoderState) {
-		state.Encoder.WriteToken(jsontext.Bool(v.Bool()))
+// mapEntry is a map entry being sorted, num is the key of integer keys.
+type mapEntry struct {
+	key   string
+	num   int64
+	unum  uint64
+	value reflect.Value
+}
+
+// marshalSortedMapEntries writes the entries of v sorted by their keys, the
+// entries are sorted in the scratch buffer of state, nested maps use the
+// buffer after the entries of v.
+func marshalSortedMapEntries(v reflect.Value, keyStringify func(v reflect.Value, state *EncoderState) string, names mapKeyNames, valueHandlerItem *handlerItem, state *EncoderState) {
+	start := len(state.mapEntries)
+	for iter := v.MapRange(); iter.Next(); {
+		entry := mapEntry{key: keyStringify(iter.Key(), state), value: iter.Value()}
+		switch iter.Key().Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			entry.num = iter.Key().Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			entry.unum = iter.Key().Uint()
+		}
+		state.mapEntries = append(state.mapEntries, entry)
 	}
-	return item
+	end := len(state.mapEntries)
+	slices.SortFunc(state.mapEntries[start:end], compareMapEntries(v.Type().Key().Kind()))
+	for i := start; i < end; i++ {
+		// state.mapEntries may be reallocated by nested maps.
+		entry := &state.mapEntries[i]
+		writeMapEntry(entry.key, names, entry.value, valueHandlerItem, state)
+	}
+	clear(state.mapEntries[start:end])
+	state.mapEntries = state.mapEntries[:start]
 }
 
-func (j *LogJson) makeDoubleHandlerItem() *handlerItem {
+func compareMapEntries(kind reflect.Kind) func(a, b mapEntry) int {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(a, b mapEntry) int {
+			return cmp.Compare(a.num, b.num)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(a, b mapEntry) int {
+			return cmp.Compare(a.unum, b.unum)
+		}
+	}
+	return func(a, b mapEntry) int {
+		return strings.Compare(a.key, b.key)
+	}
+}
+
+func (j *LogJson) makeBoolHandlerItem() *handlerItem {
 	item := &handlerItem{}
 	item.marshal = func(v reflect.Value, state *EncoderState) {
-		state.Encoder.WriteToken(jsontext.Float(v.Float()))
+		state.Encoder.WriteToken(jsontext.Bool(v.Bool()))
 	}
 	return item
 }
 
 func (j *LogJson) makeUintHandlerItem(t reflect.Type) *handlerItem {
 	item := &handlerItem{}
+	if j.getOptions().largeIntAsString {
+		item.marshal = func(v reflect.Value, state *EncoderState) {
+			writeUint(state.Encoder, v.Uint(), true)
+		}
+		return item
+	}
 	item.marshal = func(v reflect.Value, state *EncoderState) {
 		state.Encoder.WriteToken(jsontext.Uint(v.Uint()))
 	}
@@ -259,6 +658,12 @@ func (j *LogJson) makeUintHandlerItem(t reflect.Type) *handlerItem {
 
 func (j *LogJson) makeIntHandlerItem(t reflect.Type) *handlerItem {
 	item := &handlerItem{}
+	if j.getOptions().largeIntAsString {
+		item.marshal = func(v reflect.Value, state *EncoderState) {
+			writeInt(state.Encoder, v.Int(), true)
+		}
+		return item
+	}
 	item.marshal = func(v reflect.Value, state *EncoderState) {
 		state.Encoder.WriteToken(jsontext.Int(v.Int()))
 	}
@@ -267,12 +672,8 @@ func (j *LogJson) makeIntHandlerItem(t reflect.Type) *handlerItem {
 
 func (j *LogJson) makeSliceHandlerItem(t reflect.Type) *handlerItem {
 	item := &handlerItem{}
-	if t.Elem().Kind() == reflect.Uint8 {
-		item.marshal = func(v reflect.Value, state *EncoderState) {
-			val := v.Bytes()
-			base64Val := base64.RawStdEncoding.EncodeToString(val)
-			state.Encoder.WriteToken(jsontext.String(base64Val))
-		}
+	if isBytesType(t) {
+		item.marshal = createBytesMarshal(t, j.getOptions().bytesFormat)
 		return item
 	}
 	var sliceItem *handlerItem
@@ -280,139 +681,289 @@ func (j *LogJson) makeSliceHandlerItem(t reflect.Type) *handlerItem {
 	init := func() {
 		sliceItem = j.getHandlerItem(t.Elem())
 	}
+	cycleDepth := j.getOptions().cycleDetectionDepth()
+	dedupLimit := j.getOptions().dedupLimit()
 	item.marshal = func(v reflect.Value, state *EncoderState) {
-		if v.IsNil() {
+		if v.IsNil() || state.exceedMaxDepth() {
 			state.Encoder.WriteToken(jsontext.Null)
 			return
 		}
-		if state.Encoder.StackDepth() > startDetectingCyclesAfter {
+		n := v.Len()
+		id := 0
+		if dedupLimit > 0 && n > 0 {
+			key := valueId{t, v.UnsafePointer(), n}
+			if state.writeDedupRef(key) {
+				return
+			}
+			id = state.addDedupId(key, dedupLimit)
+		}
+		if state.Encoder.StackDepth() >= cycleDepth {
 			if !state.enterPointer(v) {
-				state.Encoder.WriteToken(jsontext.Null)
+				state.writeCycleRef(v)
 				return
 			}
 			defer state.leavePointer(v)
 		}
 		once.Do(init)
-		n := v.Len()
+		if id != 0 {
+			state.Encoder.WriteToken(jsontext.ObjectStart)
+			state.writeDedupId(id)
+			state.Encoder.WriteToken(jsontext.String("$values"))
+		}
 		state.Encoder.WriteToken(jsontext.ArrayStart)
 		for i := 0; i < n; i++ {
 			sliceItem.marshal(v.Index(i), state)
 		}
 		state.Encoder.WriteToken(jsontext.ArrayEnd)
+		if id != 0 {
+			state.Encoder.WriteToken(jsontext.ObjectEnd)
+		}
 	}
 	return item
 }
 
 func (j *LogJson) makePointerHandlerItem(t reflect.Type) *handlerItem {
+	return j.makePointerHandlerItemWith(t, j.getHandlerItem, j.getOptions().cycleDetectionDepth())
+}
+
+// makePointerHandlerItemWith creates the handler of the pointer type t writing
+// the values pointed to by the handler of getHandlerItem, cycles are detected
+// from the nesting depth cycleDepth.
+func (j *LogJson) makePointerHandlerItemWith(t reflect.Type, getHandlerItem func(t reflect.Type) *handlerItem, cycleDepth int) *handlerItem {
 	item := &handlerItem{}
 	var once sync.Once
 	var valItem *handlerItem
 	init := func() {
-		valItem = j.getHandlerItem(t.Elem())
+		valItem = getHandlerItem(t.Elem())
+	}
+	dedupLimit := 0
+	if t.Elem().Kind() == reflect.Struct {
+		dedupLimit = j.getOptions().dedupLimit()
 	}
 	item.marshal = func(v reflect.Value, state *EncoderState) {
-		if v.IsNil() {
+		if v.IsNil() || state.exceedMaxDepth() {
 			state.Encoder.WriteToken(jsontext.Null)
 			return
 		}
-		if state.Encoder.StackDepth() > startDetectingCyclesAfter {
+		pending := false
+		if dedupLimit > 0 {
+			key := valueId{t, v.UnsafePointer(), 0}
+			if state.writeDedupRef(key) {
+				return
+			}
+			if len(state.dedupIds) < dedupLimit {
+				state.dedupPending, state.dedupPendingLimit = key, dedupLimit
+				pending = true
+			}
+		}
+		if state.Encoder.StackDepth() >= cycleDepth {
 			if !state.enterPointer(v) {
-				state.Encoder.WriteToken(jsontext.Null)
+				state.writeCycleRef(v)
 				return
 			}
 			defer state.leavePointer(v)
 		}
 		once.Do(init)
 		valItem.marshal(v.Elem(), state)
+		if pending {
+			state.dedupPending = valueId{}
+		}
 	}
 	return item
 }
 
 func (j *LogJson) makeStructHandlerItem(t reflect.Type) *handlerItem {
 	var fields []structField
+	var hasDedupNames bool
 	var once sync.Once
 	item := &handlerItem{}
 	init := func() {
 		fields = j.parseStructFields(t)
+		names := make([]string, len(fields))
+		for i := range fields {
+			names[i] = fields[i].Name
+		}
+		hasDedupNames = hasDedupName(names)
 	}
 	item.marshal = func(v reflect.Value, state *EncoderState) {
+		if state.exceedMaxDepth() {
+			state.Encoder.WriteToken(jsontext.Null)
+			return
+		}
 		once.Do(init)
+		prevStruct, prevFields := state.curStruct, state.curFields
+		state.curStruct, state.curFields = v, fields
 		state.Encoder.WriteToken(jsontext.ObjectStart)
-		for _, field := range fields {
+		if state.dedupPending.t != nil {
+			state.writePendingDedupId(v, hasDedupNames)
+		}
+		for i := range fields {
+			field := &fields[i]
+			fieldItem := field.getHandlerItem(state)
+			if fieldItem == nil {
+				continue
+			}
 			elmV := v.FieldByIndex(field.Index)
 			if field.omitempty && isLegacyEmpty(elmV) {
 				continue
 			}
 			state.Encoder.WriteToken(jsontext.String(field.Name))
-			field.handlerItem.marshal(elmV, state)
+			fieldItem.marshal(elmV, state)
 		}
+		state.curStruct, state.curFields = prevStruct, prevFields
 		state.Encoder.WriteToken(jsontext.ObjectEnd)
 	}
 	return item
 }
 
 type structField struct {
-	Index       []int
-	Name        string
+	Index []int
+	Name  string
+	Type  reflect.Type
+	// handlerItem applies conf, it is nil when the field is omitted.
 	handlerItem *handlerItem
-	omitempty   bool
-	omit        bool
-	conf        *logRuleConf
+	// rawHandlerItem is used when conf is not active for the call.
+	rawHandlerItem *handlerItem
+	omitempty      bool
+	conf           *logRuleConf
+	// typeConf is the rule of AddTypeRule for the type of the field when conf
+	// is another rule, an omit rule of it omits the field when conf does not
+	// change the value.
+	typeConf    *logRuleConf
+	typeRuleKey string
+	// embedConf is the rule of AddTypeRule for the outermost embedded struct
+	// the field is promoted from, its omit rule omits the field and rules
+	// such as tokenize write it as null, like they do to the struct.
+	embedConf    *logRuleConf
+	embedRuleKey string
+	grants       *grantRegistry
 }
 
-func newStructField(j *LogJson, parentType reflect.Type, field reflect.StructField) structField {
+func newStructField(j *LogJson, info *structFieldInfo) structField {
 	f := structField{}
-	f.init(j, parentType, field)
-	if f.conf != nil {
-		if f.Omit() {
-			return f
-		}
-		f.handlerItem = f.conf.GetHandlerItem(field)
-	}
-	if f.handlerItem == nil {
-		f.handlerItem = j.getHandlerItem(field.Type)
-	}
+	f.init(j, info)
+	f.rawHandlerItem = j.getHandlerItem(f.Type)
+	f.handlerItem = f.conf.getHandlerItem(f.Type, f.rawHandlerItem)
 	return f
 }
 
-func (f *structField) init(j *LogJson, parentType reflect.Type, field reflect.StructField) {
-	f.handlerItem = j.getHandlerItem(field.Type)
-	f.Name = field.Name
-	f.Index = field.Index
-	f.initJsonTag(field)
-	f.conf = newLogRuleConfFromStr(field.Tag.Get("log"))
-	if f.conf != nil {
-		return
+func (f *structField) init(j *LogJson, info *structFieldInfo) {
+	f.Name = info.Name
+	f.Index = info.Index
+	f.Type = info.field.Type
+	f.omitempty = info.omitempty
+	f.grants = j.grants
+	for _, t := range info.embedded {
+		if f.embedConf = j.getTypeRule(t); f.embedConf != nil {
+			f.embedRuleKey = TypeRuleKey(t)
+			break
+		}
 	}
-	protoLogJsonStr := getFieldOptionFromType(parentType, f.Name)
-	f.conf = newLogRuleConfFromStr(protoLogJsonStr)
-	if f.conf != nil {
-		return
+	typeConf := f.initTypeRule(j)
+	if !j.ignoreFieldRules {
+		f.conf = info.tagConf
+		if f.conf != nil {
+			return
+		}
+		f.conf = info.protoConf
+		if f.conf != nil {
+			return
+		}
+		f.conf = info.typeConf
+		if f.conf != nil {
+			return
+		}
 	}
 	f.conf = j.getLogRule(f.Name)
 	if f.conf != nil {
 		return
 	}
-	return
+	f.conf = typeConf
 }
 
-func (f *structField) Omit() bool {
-	if f.omit {
-		return true
+// initTypeRule sets typeConf and returns the rule of AddTypeRule for the type
+// of the field.
+func (f *structField) initTypeRule(j *LogJson) *logRuleConf {
+	t := f.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
 	}
-	if f.conf != nil && f.conf.Omit() {
-		return true
+	f.typeConf = j.getTypeRule(t)
+	f.typeRuleKey = TypeRuleKey(t)
+	return f.typeConf
+}
+
+// getHandlerItem returns the handler for the current call, nil means the
+// field should be omitted.
+func (f *structField) getHandlerItem(state *EncoderState) *handlerItem {
+	if f.embedConf != nil {
+		if embedConf := activeLogRule(state, f.embedRuleKey, f.embedConf, f.grants); embedConf != nil {
+			if embedConf.Omit() {
+				return nil
+			}
+			if embedConf.protectsStrings() {
+				return nullHandlerItem
+			}
+		}
 	}
-	return false
+	conf := activeLogRule(state, f.Name, f.conf, f.grants)
+	if conf != nil && conf == f.typeConf {
+		conf = activeLogRule(state, f.typeRuleKey, conf, f.grants)
+	}
+	item := f.handlerItem
+	if conf != f.conf {
+		item = conf.getHandlerItem(f.Type, f.rawHandlerItem)
+	}
+	if item == f.rawHandlerItem && f.typeConf != nil && f.typeConf != f.conf {
+		if typeConf := activeLogRule(state, f.typeRuleKey, f.typeConf, f.grants); typeConf != nil && typeConf.Omit() {
+			return nil
+		}
+	}
+	return item
+}
+
+// activeLogRule returns the rule applied to the field named name for the
+// current call, conf is the rule of the field and nil means no rule.
+func activeLogRule(state *EncoderState, name string, conf *logRuleConf, grants *grantRegistry) *logRuleConf {
+	if state.hasOptions {
+		if state.opts.ignoreLogRules {
+			return nil
+		}
+		if callConf, ok := state.opts.logRules[name]; ok {
+			return callConf
+		}
+	}
+	if conf != nil && (!conf.active(state) || grants.bypass(name, conf, state)) {
+		return nil
+	}
+	return conf
 }
 
 func createMd5Marshal(t reflect.Type) func(v reflect.Value, state *EncoderState) {
+	return createStringRuleMarshal(t, md5LogString)
+}
+
+// md5LogString returns the length and the md5 of s, like 5;5d41402abc4b2a76b9719d911017c592.
+func md5LogString(s string) string {
+	hexMd5 := md5.Sum([]byte(s))
+	md5Str := hex.EncodeToString(hexMd5[:])
+	return fmt.Sprintf("%d;%s", len(s), md5Str)
+}
+
+// createStringRuleMarshal returns a marshal func writing convert(s) for string
+// and *string values, it returns nil for other types.
+func createStringRuleMarshal(t reflect.Type, convert func(s string) string) func(v reflect.Value, state *EncoderState) {
+	return createStringTokenMarshal(t, func(s string, state *EncoderState) jsontext.Token {
+		return jsontext.String(convert(s))
+	})
+}
+
+// createStringTokenMarshal is like createStringRuleMarshal, but convert decides
+// the token and can use the state of the call.
+func createStringTokenMarshal(t reflect.Type, convert func(s string, state *EncoderState) jsontext.Token) func(v reflect.Value, state *EncoderState) {
 	if t.Kind() == reflect.String {
 		return func(v reflect.Value, state *EncoderState) {
-			s := v.String()
-			hexMd5 := md5.Sum([]byte(s))
-			md5Str := hex.EncodeToString(hexMd5[:])
-			state.Encoder.WriteToken(jsontext.String(fmt.Sprintf("%d;%s", len(s), md5Str)))
+			state.Encoder.WriteToken(convert(v.String(), state))
 		}
 	}
 	if t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.String {
@@ -421,52 +972,27 @@ func createMd5Marshal(t reflect.Type) func(v reflect.Value, state *EncoderState)
 				state.Encoder.WriteToken(jsontext.Null)
 				return
 			}
-			s := v.Elem().String()
-			hexMd5 := md5.Sum([]byte(s))
-			md5Str := hex.EncodeToString(hexMd5[:])
-			state.Encoder.WriteToken(jsontext.String(fmt.Sprintf("%d;%s", len(s), md5Str)))
+			state.Encoder.WriteToken(convert(v.Elem().String(), state))
 		}
 	}
 	return nil
 }
 
-func (f *structField) initJsonTag(field reflect.StructField) {
-	parts := strings.Split(field.Tag.Get("json"), ",")
-	for i, part := range parts {
-		if i == 0 {
-			if part != "" {
-				f.Name = part
-			}
-			continue
-		}
-		switch part {
-		case "omitempty":
-			f.omitempty = true
-		}
-	}
-}
-
 func (j *LogJson) parseStructFields(t reflect.Type) []structField {
-	fields := reflect.VisibleFields(t)
+	info := j.types.getStructInfo(t)
 	var result []structField
-	for _, field := range fields {
-		if field.Anonymous {
-			continue
-		}
-		if !field.IsExported() {
-			continue
-		}
-		newField := newStructField(j, t, field)
-		if newField.Omit() {
-			continue
-		}
-		result = append(result, newField)
+	for i := range info.fields {
+		result = append(result, newStructField(j, &info.fields[i]))
 	}
 	return result
 }
 
-func (j *LogJson) makeStringHandlerItem() *handlerItem {
+func (j *LogJson) makeStringHandlerItem(t reflect.Type) *handlerItem {
 	item := &handlerItem{}
+	if j.getOptions().inlineJSON {
+		item.marshal = createInlineJSONMarshal(t, true)
+		return item
+	}
 	item.marshal = func(v reflect.Value, state *EncoderState) {
 		state.Encoder.WriteToken(jsontext.String(v.String()))
 	}
@@ -508,7 +1034,7 @@ func isLegacyEmpty(v reflect.Value) bool {
 	return false
 }
 
-func generateMarshalToStringFunc(t reflect.Type) (func(v reflect.Value) string, bool) {
+func generateMarshalToStringFunc(t reflect.Type, decimals int) (func(v reflect.Value) string, bool) {
 	var cb func(v reflect.Value) string
 	switch t.Kind() {
 	case reflect.Bool:
@@ -524,8 +1050,9 @@ func generateMarshalToStringFunc(t reflect.Type) (func(v reflect.Value) string,
 			return strconv.FormatUint(v.Uint(), 10)
 		}
 	case reflect.Float32, reflect.Float64:
+		bits := t.Bits()
 		cb = func(v reflect.Value) string {
-			return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+			return string(appendFormattedFloat(nil, v.Float(), bits, decimals))
 		}
 	case reflect.String:
 		cb = func(v reflect.Value) string {
@@ -552,18 +1079,38 @@ func getFieldOptionLogJsonValue(v reflect.Value, key string) string {
 	if field == nil {
 		return ""
 	}
-	logJsonValAny := proto.GetExtension(field.Options(), E_LogJson)
-	if s, ok := logJsonValAny.(string); ok {
-		return s
-	}
-	return ""
+	return getProtoFieldLogJsonValue(field)
 }
 
-func getFieldOptionFromType(t reflect.Type, key string) string {
-	v := reflect.New(t)
-	return getFieldOptionLogJsonValue(v, key)
+func getFieldOptionFromType(t reflect.Type, key string) *logRuleConf {
+	msg, ok := reflect.New(t).Interface().(proto.Message)
+	if !ok {
+		return nil
+	}
+	field := msg.ProtoReflect().Descriptor().Fields().ByName(protoreflect.Name(key))
+	if field == nil {
+		return nil
+	}
+	return getProtoFieldLogRuleConf(field)
 }
 
+// startDetectingCyclesAfter is the default nesting depth from which cycles
+// are detected, see SetCycleDetectionDepth.
 const startDetectingCyclesAfter = 1000
 
 var encoderStatePool sync.Pool
+
+// getPooledEncoderState returns a reset EncoderState of encoderStatePool and
+// the buffer it writes to.
+func getPooledEncoderState() (*EncoderState, *bytes.Buffer) {
+	encoderAny := encoderStatePool.Get()
+	if encoderAny == nil {
+		buf := bytes.NewBuffer(nil)
+		return NewEncoderState(buf), buf
+	}
+	encoder := encoderAny.(*EncoderState)
+	buf := encoder.GetWriter().(*bytes.Buffer)
+	buf.Reset()
+	encoder.Reset(buf)
+	return encoder, buf
+}
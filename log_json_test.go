@@ -3,7 +3,10 @@ package logjson
 import (
 	"errors"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"unsafe"
 
 	"github.com/go-json-experiment/json/jsontext"
 	"github.com/stretchr/testify/require"
@@ -85,7 +88,7 @@ func TestLogJson_Cycle(t *testing.T) {
 	}
 	abc := &Abc{}
 	abc.P = abc
-	require.Contains(t, marshalToLogStr(abc), `{"P":{"P":null}`)
+	require.Contains(t, marshalToLogStr(abc), `{"P":{"P":{"$ref":"$.P.P`)
 }
 
 func TestLogJson_Map(t *testing.T) {
@@ -180,3 +183,365 @@ func (testLogMarshaler) MarshalLogJSON(encoder *jsontext.Encoder) {
 func Test_logMarshaler(t *testing.T) {
 	require.Equal(t, `"custom"`, marshalToLogStr(testLogMarshaler(3)))
 }
+
+func Test_GetProtoMessageDefaultExtension(t *testing.T) {
+	msg := &TestProtoDefault{}
+	require.Equal(t, `md5`, getFieldOptionLogJsonValue(reflect.ValueOf(msg), "name"))
+	require.Equal(t, `omit`, getFieldOptionLogJsonValue(reflect.ValueOf(msg), "city"))
+}
+
+// Test_LogJsonProtoMessageOption shows the order rules are resolved in: the
+// log_json field option, then the log_json_default message option, then the
+// rules of LogJson.
+func Test_LogJsonProtoMessageOption(t *testing.T) {
+	msg := &TestProtoDefault{
+		Name:   proto.String("hello"),
+		City:   proto.String("city"),
+		Level:  TestProtoDefault_LEVEL_FRAUD.Enum(),
+		Secret: &TestProtoSecret{Token: proto.String("token")},
+		Hidden: &TestProtoHidden{Token: proto.String("token")},
+	}
+	buf, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg.Secret)
+	require.NoError(t, err)
+	require.Equal(t, `{"name":"5;5d41402abc4b2a76b9719d911017c592","secret":"`+md5LogString(string(buf))+`"}`,
+		marshalToLogStr(msg))
+	require.Equal(t, `{"name":"5;5d41402abc4b2a76b9719d911017c592","secret":"`+md5LogString(string(buf))+`"}`,
+		string(NewLogJson().MarshalWith(msg, WithLogRule("TestProtoSecret", LogRuleOmit()))))
+	require.Equal(t, `{"name":"5;5d41402abc4b2a76b9719d911017c592"}`,
+		string(NewLogJson().MarshalWith(msg, WithLogRule(MessageRuleKey("TestProtoSecret"), LogRuleOmit()))))
+	require.Equal(t, `{"name":"hello","city":"city","level":"LEVEL_FRAUD","secret":{"token":"token"},"hidden":{"token":"token"}}`,
+		string(NewLogJson().MarshalWith(msg, WithoutLogRules())))
+	require.Equal(t, `null`, marshalToLogStr(&TestProtoHidden{Token: proto.String("token")}))
+
+	j := NewLogJson()
+	j.AddLogRule("name", LogRuleOmit())
+	require.Equal(t, `{"name":"5;5d41402abc4b2a76b9719d911017c592"}`,
+		string(j.Marshal(&TestProtoDefault{Name: proto.String("hello")})))
+}
+
+func Test_LogJsonProtoRuleOption(t *testing.T) {
+	msg := &TestProtoRule{
+		Phone: proto.String("13812345678"),
+		Email: proto.String("hello"),
+		Note:  proto.String("note"),
+	}
+	require.Equal(t, `{"phone":"138******78","email":"5;2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824","note":"note"}`,
+		marshalToLogStr(msg))
+	require.Nil(t, getFieldOptionFromType(reflect.TypeFor[TestProtoRule](), "note"))
+	require.Equal(t, "pii", newLogRuleConfFromProto(&LogJsonRule{
+		Action:         LogJsonRule_ACTION_OMIT.Enum(),
+		Classification: proto.String("pii"),
+	}).classification)
+
+	j := NewLogJson()
+	j.AddLogRule("note", LogRuleOmit())
+	require.Equal(t, `{"phone":"138******78","email":"5;2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"}`,
+		string(j.Marshal(msg)))
+}
+
+func TestLogJson_MarshalMaskTag(t *testing.T) {
+	type Abc struct {
+		Phone string `log:"mask=3:2"`
+		Short string `log:"mask=3:2"`
+		Bad   string `log:"mask=3"`
+	}
+	require.Equal(t, `{"Phone":"138******78","Short":"****","Bad":"abc"}`,
+		marshalToLogStr(Abc{Phone: "13812345678", Short: "abcd", Bad: "abc"}))
+}
+
+func TestLogJson_MarshalSha256Tag(t *testing.T) {
+	type Abc struct {
+		Email string `log:"sha256"`
+	}
+	require.Equal(t, `{"Email":"5;2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"}`,
+		marshalToLogStr(Abc{Email: "hello"}))
+}
+
+type testStateLogMarshaler struct {
+	Name  string
+	Inner any
+}
+
+func (m testStateLogMarshaler) MarshalLogJSONState(state *EncoderState) {
+	state.WriteToken(jsontext.ObjectStart)
+	state.WriteToken(jsontext.String("name"))
+	state.WriteToken(jsontext.String(m.Name))
+	state.WriteToken(jsontext.String("inner"))
+	state.MarshalValue(m.Inner)
+	state.WriteToken(jsontext.ObjectEnd)
+}
+
+func (testStateLogMarshaler) MarshalLogJSON(encoder *jsontext.Encoder) {
+	encoder.WriteToken(jsontext.String("custom"))
+}
+
+func Test_stateLogMarshaler(t *testing.T) {
+	type Inner struct {
+		Password string
+		Token    string `log:"md5"`
+	}
+	j := NewLogJson()
+	j.AddLogRule("Password", LogRuleOmit())
+	m := testStateLogMarshaler{Name: "abc", Inner: Inner{Password: "password", Token: "hello"}}
+	require.Equal(t, `{"name":"abc","inner":{"Token":"5;5d41402abc4b2a76b9719d911017c592"}}`, string(j.Marshal(m)))
+	require.Equal(t, `{"name":"abc","inner":{"Password":"password","Token":"hello"}}`,
+		string(j.MarshalWith(m, WithoutLogRules())))
+	require.Equal(t, `{"name":"abc","inner":{"Password":"password","Token":"5;5d41402abc4b2a76b9719d911017c592"}}`,
+		marshalToLogStr(m))
+}
+
+type testPtrLogMarshaler struct {
+	Secret string
+}
+
+func (m *testPtrLogMarshaler) MarshalLogJSON(encoder *jsontext.Encoder) {
+	encoder.WriteToken(jsontext.String("custom"))
+}
+
+type testPtrError struct {
+	Code int
+}
+
+func (e *testPtrError) Error() string {
+	return "code " + strconv.Itoa(e.Code)
+}
+
+func Test_pointerReceiverMarshaler(t *testing.T) {
+	type Abc struct {
+		M   testPtrLogMarshaler
+		Err testPtrError
+	}
+	abc := &Abc{M: testPtrLogMarshaler{Secret: "secret"}, Err: testPtrError{Code: 3}}
+	require.Equal(t, `{"M":"custom","Err":"code 3"}`, marshalToLogStr(abc))
+	require.Equal(t, `{"M":"custom","Err":"code 3"}`, marshalToLogStr(*abc))
+	require.Equal(t, `"custom"`, marshalToLogStr(testPtrLogMarshaler{Secret: "secret"}))
+	require.Equal(t, `{"a":"custom"}`, marshalToLogStr(map[string]testPtrLogMarshaler{"a": {Secret: "secret"}}))
+	require.Equal(t, `["code 1"]`, marshalToLogStr([]testPtrError{{Code: 1}}))
+	require.Equal(t, `["custom"]`, marshalToLogStr([]any{testPtrLogMarshaler{Secret: "secret"}}))
+}
+
+type testAddress struct {
+	Country string
+	City    string
+	Street  string
+	Phone   string
+}
+
+func (testAddress) LogJSONRules() map[string]LogRule {
+	return map[string]LogRule{
+		"*":       LogRuleOmit(),
+		"Country": LogRuleKeep(),
+		"City":    LogRuleKeep(),
+	}
+}
+
+type testCredentials struct {
+	User     string
+	Password string
+}
+
+func TestLogJson_TypeRules(t *testing.T) {
+	type Abc struct {
+		Home  testAddress
+		Work  *testAddress
+		Cred  testCredentials
+		Creds []testCredentials
+		Ptr   *testCredentials
+		Key   testKey         `log:"md5"`
+		Hash  testCredentials `log:"md5"`
+	}
+	j := NewLogJson()
+	abc := Abc{
+		Home:  testAddress{Country: "CN", City: "SZ", Street: "street", Phone: "123"},
+		Work:  &testAddress{Country: "US", Street: "street"},
+		Cred:  testCredentials{User: "user", Password: "password"},
+		Creds: []testCredentials{{User: "user"}},
+		Ptr:   &testCredentials{User: "user"},
+		Key:   "hello",
+	}
+	require.Equal(t, `{"Home":{"Country":"CN","City":"SZ"},"Work":{"Country":"US","City":""},"Cred":{"User":"user","Password":"password"},"Creds":[{"User":"user","Password":""}],"Ptr":{"User":"user","Password":""},"Key":"5;5d41402abc4b2a76b9719d911017c592","Hash":{"User":"","Password":""}}`,
+		string(j.Marshal(abc)))
+	j.AddTypeRule(reflect.TypeFor[testCredentials](), LogRuleOmit())
+	j.AddTypeRule(reflect.TypeFor[testKey](), LogRuleMask(1, 1))
+	require.Equal(t, `{"Home":{"Country":"CN","City":"SZ"},"Work":{"Country":"US","City":""},"Creds":[null],"Key":"5;5d41402abc4b2a76b9719d911017c592"}`,
+		string(j.Marshal(abc)))
+	require.Equal(t, `"h***o"`, string(j.Marshal(testKey("hello"))))
+	require.Equal(t, `{"User":"user","Password":""}`, string(j.MarshalWith(testCredentials{User: "user"}, WithoutLogRules())))
+
+	credKey := TypeRuleKey(reflect.TypeFor[testCredentials]())
+	require.Equal(t, "type:logjson.testCredentials", credKey)
+	require.Equal(t, `{"Home":{"Country":"CN","City":"SZ"},"Work":{"Country":"US","City":""},"Cred":{"User":"user","Password":"password"},"Creds":[{"User":"user","Password":""}],"Ptr":{"User":"user","Password":""},"Key":"5;5d41402abc4b2a76b9719d911017c592","Hash":{"User":"","Password":""}}`,
+		string(j.MarshalWith(abc, WithLogRule(credKey, LogRuleKeep()))))
+	require.Contains(t, string(j.MarshalWith(abc, WithLogRule("logjson.testCredentials", LogRuleKeep()))), `"Creds":[null]`)
+}
+
+type testOrder struct {
+	testAddress
+	OrderId int
+	Amount  int
+}
+
+type testNotedOrder struct {
+	*testAddress
+	Note    string
+	OrderId int
+}
+
+func (*testNotedOrder) LogJSONRules() map[string]LogRule {
+	return map[string]LogRule{
+		"Note": LogRuleOmit(),
+	}
+}
+
+func TestLogJson_EmbeddedLogJSONRules(t *testing.T) {
+	j := NewLogJson()
+	address := testAddress{Country: "CN", City: "SZ", Street: "street", Phone: "123"}
+	require.Equal(t, `{"Country":"CN","City":"SZ","OrderId":1,"Amount":2}`,
+		string(j.Marshal(testOrder{testAddress: address, OrderId: 1, Amount: 2})))
+	require.Equal(t, `{"Country":"CN","City":"SZ","OrderId":1}`,
+		string(j.Marshal(&testNotedOrder{testAddress: &address, Note: "note", OrderId: 1})))
+}
+
+func TestLogJson_EmbeddedTypeRules(t *testing.T) {
+	type Account struct {
+		testCredentials
+		Name string
+	}
+	type PtrAccount struct {
+		*testCredentials
+		Name string
+	}
+	j := NewLogJson()
+	account := Account{testCredentials: testCredentials{User: "u", Password: "pw"}, Name: "n"}
+	require.Equal(t, `{"User":"u","Password":"pw","Name":"n"}`, string(j.Marshal(account)))
+	j.AddTypeRule(reflect.TypeFor[testCredentials](), LogRuleOmit())
+	require.Equal(t, `{"Name":"n"}`, string(j.Marshal(account)))
+	require.Equal(t, `{"Name":"n"}`, string(j.Marshal(PtrAccount{testCredentials: &account.testCredentials, Name: "n"})))
+	credKey := TypeRuleKey(reflect.TypeFor[testCredentials]())
+	require.Equal(t, `{"User":"u","Password":"pw","Name":"n"}`, string(j.MarshalWith(account, WithLogRule(credKey, LogRuleKeep()))))
+
+	tokenizer, err := NewTokenizer(make([]byte, 32))
+	require.NoError(t, err)
+	j.AddTypeRule(reflect.TypeFor[testCredentials](), LogRuleTokenize(tokenizer))
+	require.Equal(t, `{"User":null,"Password":null,"Name":"n"}`, string(j.Marshal(account)))
+}
+
+func TestLogJson_AddLogRuleAfterMarshal(t *testing.T) {
+	type Abc struct {
+		Name string
+	}
+	j := NewLogJson()
+	require.Equal(t, `{"Name":"hello"}`, string(j.Marshal(Abc{Name: "hello"})))
+	j.AddLogRule("Name", LogRuleOmit())
+	require.Equal(t, `{}`, string(j.Marshal(Abc{Name: "hello"})))
+}
+
+type testKey string
+
+func TestLogJson_MapKeyCollisions(t *testing.T) {
+	j := NewLogJson()
+	one, otherOne := 1, 1
+	for _, sortKeys := range []bool{false, true} {
+		j.SetSortMapKeys(sortKeys)
+		out := j.Marshal(map[any]int{1: 1, "1": 1})
+		require.True(t, jsontext.Value(out).IsValid())
+		require.Equal(t, `{"1":1,"1#2":1}`, string(out))
+		out = j.Marshal(map[*int]int{&one: 1, &otherOne: 1})
+		require.Equal(t, `{"1":1,"1#2":1}`, string(out))
+		out = j.Marshal(map[any]int{1: 1, "1": 1, "1#2": 1})
+		require.True(t, jsontext.Value(out).IsValid())
+		require.Equal(t, 3, strings.Count(string(out), `":1`))
+	}
+}
+
+func TestLogJson_SortMapKeys(t *testing.T) {
+	j := NewLogJson()
+	j.SetSortMapKeys(true)
+	in := map[string]any{
+		"b":    map[int]string{10: "ten", -1: "minus", 2: "two"},
+		"a":    map[uint8]int{200: 1, 3: 2},
+		"c":    []map[string]int{{"y": 1, "x": 2}},
+		"b10":  1,
+		"b2":   2,
+		"bool": map[bool]int{true: 1, false: 0},
+	}
+	expected := `{"a":{"3":2,"200":1},"b":{"-1":"minus","2":"two","10":"ten"},"b10":1,"b2":2,"bool":{"false":0,"true":1},"c":[{"x":2,"y":1}]}`
+	for i := 0; i < 10; i++ {
+		require.Equal(t, expected, string(j.Marshal(in)))
+	}
+}
+
+type testUserId int64
+
+func (id testUserId) MarshalText() ([]byte, error) {
+	return []byte("user-" + strconv.FormatInt(int64(id), 10)), nil
+}
+
+type testLevel int
+
+func (l *testLevel) String() string {
+	return "level-" + strconv.Itoa(int(*l))
+}
+
+type testKeyPair struct {
+	A, B     int
+	Password string
+}
+
+func TestLogJson_MapKeys(t *testing.T) {
+	j := NewLogJson()
+	j.SetSortMapKeys(true)
+	j.AddLogRule("Password", LogRuleOmit())
+	one, two := 1, 2
+	require.Equal(t, `{"user-1":1,"user-2":2}`, string(j.Marshal(map[testUserId]int{2: 2, 1: 1})))
+	require.Equal(t, `{"1":1,"2":2}`, string(j.Marshal(map[testLevel]int{2: 2, 1: 1})))
+	require.Equal(t, `{"{\"A\":1,\"B\":2}":1}`, string(j.Marshal(map[testKeyPair]int{{A: 1, B: 2, Password: "password"}: 1})))
+	require.Equal(t, `{"[1,2]":1}`, string(j.Marshal(map[[2]int]int{{1, 2}: 1})))
+	require.Equal(t, `{"1":1,"2":2,"null":0}`, string(j.Marshal(map[*int]int{&one: 1, &two: 2, nil: 0})))
+	require.Equal(t, `{"1":1,"a":2,"null":0,"true":4,"user-3":3}`,
+		string(j.Marshal(map[any]int{1: 1, "a": 2, nil: 0, testUserId(3): 3, true: 4})))
+	require.Equal(t, `{"{\"A\":1,\"B\":2,\"Password\":\"password\"}":1}`,
+		string(j.MarshalWith(map[testKeyPair]int{{A: 1, B: 2, Password: "password"}: 1}, WithoutLogRules())))
+
+	require.Equal(t, `{"{\"A\":1,\"B\":2}":1,"{\"A\":1,\"B\":2}#2":1}`,
+		string(j.Marshal(map[testKeyPair]int{{A: 1, B: 2, Password: "a"}: 1, {A: 1, B: 2, Password: "b"}: 1})))
+
+	j.SetMapKeyStringer(true)
+	require.Equal(t, `{"level-1":1,"level-2":2}`, string(j.Marshal(map[testLevel]int{2: 2, 1: 1})))
+
+	j.SetStrictMapKeys(true)
+	require.Equal(t, `{"1":1}`, string(j.Marshal(map[testUserId]int{1: 1})))
+	require.Equal(t, `null`, string(j.Marshal(map[testKeyPair]int{{A: 1}: 1})))
+}
+
+func testOpaqueFunc() {}
+
+func TestLogJson_DescribeOpaqueKinds(t *testing.T) {
+	type Abc struct {
+		Done    chan struct{}
+		Results <-chan int
+		Handler func()
+		Nil     func()
+		C64     complex64
+		C128    complex128
+		Ptr     uintptr
+		Unsafe  unsafe.Pointer
+	}
+	results := make(chan int, 5)
+	results <- 1
+	results <- 2
+	results <- 3
+	abc := Abc{
+		Results: results,
+		Handler: testOpaqueFunc,
+		C64:     complex(1, 2),
+		C128:    complex(0.5, -1),
+		Ptr:     0xc000012345,
+	}
+	require.Equal(t, `{"Done":null,"Results":null,"Handler":null,"Nil":null,"C64":null,"C128":null,"Ptr":null,"Unsafe":null}`,
+		marshalToLogStr(abc))
+	j := NewLogJson()
+	j.SetDescribeOpaqueKinds(true)
+	require.Equal(t, `{"Done":null,"Results":"<-chan int(len=3,cap=5)","Handler":"github.com/ethanvc/logjson.testOpaqueFunc","Nil":null,"C64":"1+2i","C128":"0.5-1i","Ptr":"0xc000012345","Unsafe":null}`,
+		string(j.Marshal(abc)))
+}
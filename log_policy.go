@@ -0,0 +1,26 @@
+package logjson
+
+// LogPolicy is a set of rules applied by a LogJson view, see LogJson.View.
+type LogPolicy struct {
+	rules            map[string]LogRule
+	ignoreFieldRules bool
+}
+
+func NewLogPolicy() *LogPolicy {
+	return &LogPolicy{
+		rules: make(map[string]LogRule),
+	}
+}
+
+// AddLogRule adds a rule for fields named key, like LogJson.AddLogRule.
+func (p *LogPolicy) AddLogRule(key string, rule LogRule) *LogPolicy {
+	p.rules[key] = rule
+	return p
+}
+
+// IgnoreFieldRules makes the view ignore log tags and log_json proto options,
+// which is useful for unredacted sinks.
+func (p *LogPolicy) IgnoreFieldRules() *LogPolicy {
+	p.ignoreFieldRules = true
+	return p
+}
@@ -0,0 +1,21 @@
+package logjson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogJson_View(t *testing.T) {
+	type Abc struct {
+		Name     string
+		BankCard string `log:"md5"`
+	}
+	abc := Abc{Name: "hello", BankCard: "hello"}
+	j := NewLogJson()
+	redacted := j.View(NewLogPolicy().AddLogRule("Name", LogRuleOmit()))
+	unredacted := j.View(NewLogPolicy().IgnoreFieldRules())
+	require.Equal(t, `{"Name":"hello","BankCard":"5;5d41402abc4b2a76b9719d911017c592"}`, string(j.Marshal(abc)))
+	require.Equal(t, `{"BankCard":"5;5d41402abc4b2a76b9719d911017c592"}`, string(redacted.Marshal(abc)))
+	require.Equal(t, `{"Name":"hello","BankCard":"hello"}`, string(unredacted.Marshal(abc)))
+}
@@ -1,12 +1,41 @@
 package logjson
 
-import "reflect"
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-json-experiment/json/jsontext"
+)
 
 type LogRule func(conf *logRuleConf)
 
 type logRuleConf struct {
-	md5  bool
-	omit bool
+	// name is the name of the rule used in log tags, such as "md5".
+	name   string
+	md5    bool
+	sha256 bool
+	omit   bool
+	mask   *maskRule
+	cond   func(ctx context.Context) bool
+	// classification is the label of the data, such as "pii", it does not
+	// change the output.
+	classification string
+	// tokenizer is set by LogRuleTokenize.
+	tokenizer *Tokenizer
+	// shred is set by LogRuleShred.
+	shred *shredRule
+	// bytes is set by LogRuleBytes.
+	bytes *BytesFormat
+	// inlineJSON is set by LogRuleInlineJSON.
+	inlineJSON bool
+	// enumFormat is set by LogRuleEnum.
+	enumFormat *EnumFormat
 }
 
 func newLogRuleConfFromStr(ruleStr string) *logRuleConf {
@@ -19,6 +48,28 @@ func newLogRuleConfFromStr(ruleStr string) *logRuleConf {
 	return conf
 }
 
+// newLogRuleConfFromProto creates the rule of a log_json_rule proto option, it
+// returns nil when the option has no action.
+func newLogRuleConfFromProto(rule *LogJsonRule) *logRuleConf {
+	conf := &logRuleConf{}
+	switch rule.GetAction() {
+	case LogJsonRule_ACTION_OMIT:
+		conf.init(LogRuleOmit())
+	case LogJsonRule_ACTION_HASH:
+		if rule.GetHashAlgorithm() == LogJsonRule_HASH_ALGORITHM_SHA256 {
+			conf.init(LogRuleSha256())
+		} else {
+			conf.init(LogRuleMd5())
+		}
+	case LogJsonRule_ACTION_MASK:
+		conf.init(LogRuleMask(max(int(rule.GetMaskPrefix()), 0), max(int(rule.GetMaskSuffix()), 0)))
+	default:
+		return nil
+	}
+	conf.classification = rule.GetClassification()
+	return conf
+}
+
 func newLogRuleConf(rule LogRule) *logRuleConf {
 	conf := &logRuleConf{}
 	conf.init(rule)
@@ -31,9 +82,35 @@ func (conf *logRuleConf) parseLogRule(ruleStr string) LogRule {
 		return LogRuleOmit()
 	case "md5":
 		return LogRuleMd5()
-	default:
-		return nil
+	case "sha256":
+		return LogRuleSha256()
+	case "json":
+		return LogRuleInlineJSON()
+	}
+	if maskStr, ok := strings.CutPrefix(ruleStr, "mask="); ok {
+		prefixStr, suffixStr, _ := strings.Cut(maskStr, ":")
+		prefix, err1 := strconv.Atoi(prefixStr)
+		suffix, err2 := strconv.Atoi(suffixStr)
+		if err1 != nil || err2 != nil || prefix < 0 || suffix < 0 {
+			return nil
+		}
+		return LogRuleMask(prefix, suffix)
 	}
+	if formatStr, ok := strings.CutPrefix(ruleStr, "enum="); ok {
+		format, ok := enumFormatNames[formatStr]
+		if !ok {
+			return nil
+		}
+		return LogRuleEnum(format)
+	}
+	if formatStr, ok := strings.CutPrefix(ruleStr, "bytes="); ok {
+		format, ok := parseBytesFormat(formatStr)
+		if !ok {
+			return nil
+		}
+		return LogRuleBytes(format)
+	}
+	return nil
 }
 
 func (conf *logRuleConf) init(rule LogRule) {
@@ -44,30 +121,170 @@ func (conf *logRuleConf) Omit() bool {
 	return conf.omit
 }
 
-func (conf *logRuleConf) GetHandlerItem(field reflect.StructField) *handlerItem {
+// active reports whether the rule applies to the current call.
+func (conf *logRuleConf) active(state *EncoderState) bool {
+	if conf.cond == nil {
+		return true
+	}
+	return conf.cond(state.Context())
+}
+
+// getHandlerItem returns the handler which applies the rule to values of type
+// t, rawItem is returned when the rule does not change the output. A nil
+// result means the value should be omitted.
+func (conf *logRuleConf) getHandlerItem(t reflect.Type, rawItem *handlerItem) *handlerItem {
+	if conf == nil {
+		return rawItem
+	}
 	if conf.Omit() {
 		return nil
 	}
 	var marshal func(v reflect.Value, state *EncoderState)
-	if conf.md5 {
-		marshal = createMd5Marshal(field.Type)
+	if hash := conf.hashFunc(); hash != nil {
+		marshal = createStringRuleMarshal(t, hash)
+	} else if conf.mask != nil {
+		marshal = createStringRuleMarshal(t, conf.mask.apply)
+	} else if conf.tokenizer != nil {
+		marshal = createStringRuleMarshal(t, conf.tokenizer.Tokenize)
+	} else if conf.shred != nil {
+		marshal = createStringTokenMarshal(t, conf.shred.token)
+	} else if conf.bytes != nil {
+		marshal = createBytesMarshal(t, *conf.bytes)
+	} else if conf.inlineJSON {
+		marshal = createInlineJSONMarshal(t, false)
+	} else if conf.enumFormat != nil {
+		marshal = createEnumMarshal(t, *conf.enumFormat)
+	}
+	if marshal == nil && conf.protectsStrings() {
+		return nullHandlerItem
 	}
 	if marshal == nil {
-		return nil
+		return rawItem
 	}
 	return &handlerItem{
 		marshal: marshal,
 	}
 }
 
+// protectsStrings reports whether the rule only keeps strings recoverable by
+// a key, such as tokenize and shred. Values of other kinds are written as null
+// instead of in clear.
+func (conf *logRuleConf) protectsStrings() bool {
+	return conf.tokenizer != nil || conf.shred != nil
+}
+
+func writeNullMarshal(v reflect.Value, state *EncoderState) {
+	state.Encoder.WriteToken(jsontext.Null)
+}
+
+var nullHandlerItem = &handlerItem{marshal: writeNullMarshal}
+
+// applyString returns s with the rule applied, it reports false when the rule
+// does not change strings by itself, such as omit rules.
+func (conf *logRuleConf) applyString(s string) (string, bool) {
+	switch {
+	case conf.hashFunc() != nil:
+		return conf.hashFunc()(s), true
+	case conf.mask != nil:
+		return conf.mask.apply(s), true
+	case conf.tokenizer != nil:
+		return conf.tokenizer.Tokenize(s), true
+	}
+	return s, false
+}
+
+// changesStrings reports whether applyString changes strings.
+func (conf *logRuleConf) changesStrings() bool {
+	return conf.hashFunc() != nil || conf.mask != nil || conf.tokenizer != nil
+}
+
+// hashFunc returns the hash function of the rule, or nil when the rule does not
+// hash.
+func (conf *logRuleConf) hashFunc() func(s string) string {
+	switch {
+	case conf.md5:
+		return md5LogString
+	case conf.sha256:
+		return sha256LogString
+	}
+	return nil
+}
+
+// sha256LogString returns the length and the sha256 of s, like md5LogString.
+func sha256LogString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return fmt.Sprintf("%d;%s", len(s), hex.EncodeToString(sum[:]))
+}
+
+type maskRule struct {
+	prefix int
+	suffix int
+}
+
+// apply keeps prefix and suffix characters of s and replaces the others with
+// '*', all characters are replaced when s is not longer than prefix+suffix.
+func (m *maskRule) apply(s string) string {
+	n := utf8.RuneCountInString(s)
+	maskAll := n <= m.prefix+m.suffix
+	var b strings.Builder
+	i := 0
+	for _, r := range s {
+		if !maskAll && (i < m.prefix || i >= n-m.suffix) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('*')
+		}
+		i++
+	}
+	return b.String()
+}
+
 func LogRuleMd5() LogRule {
 	return func(conf *logRuleConf) {
+		conf.name = "md5"
 		conf.md5 = true
 	}
 }
 
+// LogRuleSha256 replaces string values with their length and sha256, like
+// LogRuleMd5. It is "sha256" in log tags.
+func LogRuleSha256() LogRule {
+	return func(conf *logRuleConf) {
+		conf.name = "sha256"
+		conf.sha256 = true
+	}
+}
+
+// LogRuleMask keeps the first prefix and the last suffix characters of string
+// values and replaces the others with '*'. It is "mask=prefix:suffix" in log
+// tags.
+func LogRuleMask(prefix, suffix int) LogRule {
+	return func(conf *logRuleConf) {
+		conf.name = "mask"
+		conf.mask = &maskRule{prefix: prefix, suffix: suffix}
+	}
+}
+
+// LogRuleKeep keeps values as is, it overrides a default rule such as the "*"
+// rule of LogJSONRules.
+func LogRuleKeep() LogRule {
+	return func(conf *logRuleConf) {
+		conf.name = "keep"
+	}
+}
+
 func LogRuleOmit() LogRule {
 	return func(conf *logRuleConf) {
+		conf.name = "omit"
 		conf.omit = true
 	}
 }
+
+// LogRuleIf applies rule only when cond reports true for the context of the
+// marshal call, see WithContext.
+func LogRuleIf(cond func(ctx context.Context) bool, rule LogRule) LogRule {
+	return func(conf *logRuleConf) {
+		rule(conf)
+		conf.cond = cond
+	}
+}
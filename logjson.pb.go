@@ -11,6 +11,7 @@ import (
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
 	reflect "reflect"
+	sync "sync"
 )
 
 const (
@@ -20,6 +21,206 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type LogJsonRule_Action int32
+
+const (
+	LogJsonRule_ACTION_UNSPECIFIED LogJsonRule_Action = 0
+	LogJsonRule_ACTION_OMIT        LogJsonRule_Action = 1
+	LogJsonRule_ACTION_HASH        LogJsonRule_Action = 2
+	LogJsonRule_ACTION_MASK        LogJsonRule_Action = 3
+)
+
+// Enum value maps for LogJsonRule_Action.
+var (
+	LogJsonRule_Action_name = map[int32]string{
+		0: "ACTION_UNSPECIFIED",
+		1: "ACTION_OMIT",
+		2: "ACTION_HASH",
+		3: "ACTION_MASK",
+	}
+	LogJsonRule_Action_value = map[string]int32{
+		"ACTION_UNSPECIFIED": 0,
+		"ACTION_OMIT":        1,
+		"ACTION_HASH":        2,
+		"ACTION_MASK":        3,
+	}
+)
+
+func (x LogJsonRule_Action) Enum() *LogJsonRule_Action {
+	p := new(LogJsonRule_Action)
+	*p = x
+	return p
+}
+
+func (x LogJsonRule_Action) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (LogJsonRule_Action) Descriptor() protoreflect.EnumDescriptor {
+	return file_logjson_proto_enumTypes[0].Descriptor()
+}
+
+func (LogJsonRule_Action) Type() protoreflect.EnumType {
+	return &file_logjson_proto_enumTypes[0]
+}
+
+func (x LogJsonRule_Action) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Do not use.
+func (x *LogJsonRule_Action) UnmarshalJSON(b []byte) error {
+	num, err := protoimpl.X.UnmarshalJSONEnum(x.Descriptor(), b)
+	if err != nil {
+		return err
+	}
+	*x = LogJsonRule_Action(num)
+	return nil
+}
+
+// Deprecated: Use LogJsonRule_Action.Descriptor instead.
+func (LogJsonRule_Action) EnumDescriptor() ([]byte, []int) {
+	return file_logjson_proto_rawDescGZIP(), []int{0, 0}
+}
+
+type LogJsonRule_HashAlgorithm int32
+
+const (
+	LogJsonRule_HASH_ALGORITHM_MD5    LogJsonRule_HashAlgorithm = 0
+	LogJsonRule_HASH_ALGORITHM_SHA256 LogJsonRule_HashAlgorithm = 1
+)
+
+// Enum value maps for LogJsonRule_HashAlgorithm.
+var (
+	LogJsonRule_HashAlgorithm_name = map[int32]string{
+		0: "HASH_ALGORITHM_MD5",
+		1: "HASH_ALGORITHM_SHA256",
+	}
+	LogJsonRule_HashAlgorithm_value = map[string]int32{
+		"HASH_ALGORITHM_MD5":    0,
+		"HASH_ALGORITHM_SHA256": 1,
+	}
+)
+
+func (x LogJsonRule_HashAlgorithm) Enum() *LogJsonRule_HashAlgorithm {
+	p := new(LogJsonRule_HashAlgorithm)
+	*p = x
+	return p
+}
+
+func (x LogJsonRule_HashAlgorithm) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (LogJsonRule_HashAlgorithm) Descriptor() protoreflect.EnumDescriptor {
+	return file_logjson_proto_enumTypes[1].Descriptor()
+}
+
+func (LogJsonRule_HashAlgorithm) Type() protoreflect.EnumType {
+	return &file_logjson_proto_enumTypes[1]
+}
+
+func (x LogJsonRule_HashAlgorithm) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Do not use.
+func (x *LogJsonRule_HashAlgorithm) UnmarshalJSON(b []byte) error {
+	num, err := protoimpl.X.UnmarshalJSONEnum(x.Descriptor(), b)
+	if err != nil {
+		return err
+	}
+	*x = LogJsonRule_HashAlgorithm(num)
+	return nil
+}
+
+// Deprecated: Use LogJsonRule_HashAlgorithm.Descriptor instead.
+func (LogJsonRule_HashAlgorithm) EnumDescriptor() ([]byte, []int) {
+	return file_logjson_proto_rawDescGZIP(), []int{0, 1}
+}
+
+type LogJsonRule struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Action *LogJsonRule_Action `protobuf:"varint,1,opt,name=action,enum=LogJsonRule_Action" json:"action,omitempty"`
+	// mask_prefix and mask_suffix are the characters ACTION_MASK keeps.
+	MaskPrefix *int32 `protobuf:"varint,2,opt,name=mask_prefix,json=maskPrefix" json:"mask_prefix,omitempty"`
+	MaskSuffix *int32 `protobuf:"varint,3,opt,name=mask_suffix,json=maskSuffix" json:"mask_suffix,omitempty"`
+	// hash_algorithm is used by ACTION_HASH.
+	HashAlgorithm *LogJsonRule_HashAlgorithm `protobuf:"varint,4,opt,name=hash_algorithm,json=hashAlgorithm,enum=LogJsonRule_HashAlgorithm" json:"hash_algorithm,omitempty"`
+	// classification is the label of the data, such as "pii".
+	Classification *string `protobuf:"bytes,5,opt,name=classification" json:"classification,omitempty"`
+}
+
+func (x *LogJsonRule) Reset() {
+	*x = LogJsonRule{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_logjson_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LogJsonRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogJsonRule) ProtoMessage() {}
+
+func (x *LogJsonRule) ProtoReflect() protoreflect.Message {
+	mi := &file_logjson_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogJsonRule.ProtoReflect.Descriptor instead.
+func (*LogJsonRule) Descriptor() ([]byte, []int) {
+	return file_logjson_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LogJsonRule) GetAction() LogJsonRule_Action {
+	if x != nil && x.Action != nil {
+		return *x.Action
+	}
+	return LogJsonRule_ACTION_UNSPECIFIED
+}
+
+func (x *LogJsonRule) GetMaskPrefix() int32 {
+	if x != nil && x.MaskPrefix != nil {
+		return *x.MaskPrefix
+	}
+	return 0
+}
+
+func (x *LogJsonRule) GetMaskSuffix() int32 {
+	if x != nil && x.MaskSuffix != nil {
+		return *x.MaskSuffix
+	}
+	return 0
+}
+
+func (x *LogJsonRule) GetHashAlgorithm() LogJsonRule_HashAlgorithm {
+	if x != nil && x.HashAlgorithm != nil {
+		return *x.HashAlgorithm
+	}
+	return LogJsonRule_HASH_ALGORITHM_MD5
+}
+
+func (x *LogJsonRule) GetClassification() string {
+	if x != nil && x.Classification != nil {
+		return *x.Classification
+	}
+	return ""
+}
+
 var file_logjson_proto_extTypes = []protoimpl.ExtensionInfo{
 	{
 		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
@@ -29,12 +230,71 @@ var file_logjson_proto_extTypes = []protoimpl.ExtensionInfo{
 		Tag:           "bytes,98721,opt,name=log_json",
 		Filename:      "logjson.proto",
 	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*LogJsonRule)(nil),
+		Field:         98722,
+		Name:          "log_json_rule",
+		Tag:           "bytes,98722,opt,name=log_json_rule",
+		Filename:      "logjson.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.MessageOptions)(nil),
+		ExtensionType: (*string)(nil),
+		Field:         98721,
+		Name:          "log_json_message",
+		Tag:           "bytes,98721,opt,name=log_json_message",
+		Filename:      "logjson.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.MessageOptions)(nil),
+		ExtensionType: (*string)(nil),
+		Field:         98722,
+		Name:          "log_json_default",
+		Tag:           "bytes,98722,opt,name=log_json_default",
+		Filename:      "logjson.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.EnumValueOptions)(nil),
+		ExtensionType: (*bool)(nil),
+		Field:         98721,
+		Name:          "log_json_hide",
+		Tag:           "varint,98721,opt,name=log_json_hide",
+		Filename:      "logjson.proto",
+	},
 }
 
 // Extension fields to descriptorpb.FieldOptions.
 var (
 	// optional string log_json = 98721;
 	E_LogJson = &file_logjson_proto_extTypes[0]
+	// log_json_rule is the structured form of log_json, it is preferred when
+	// both are set.
+	//
+	// optional LogJsonRule log_json_rule = 98722;
+	E_LogJsonRule = &file_logjson_proto_extTypes[1]
+)
+
+// Extension fields to descriptorpb.MessageOptions.
+var (
+	// log_json_message applies to the whole message wherever it is logged,
+	// "omit" omits it and "md5" writes the md5 of its serialized bytes.
+	//
+	// optional string log_json_message = 98721;
+	E_LogJsonMessage = &file_logjson_proto_extTypes[2]
+	// log_json_default applies to the fields without log_json option.
+	//
+	// optional string log_json_default = 98722;
+	E_LogJsonDefault = &file_logjson_proto_extTypes[3]
+)
+
+// Extension fields to descriptorpb.EnumValueOptions.
+var (
+	// log_json_hide omits the fields, list elements and map entries holding
+	// the value.
+	//
+	// optional bool log_json_hide = 98721;
+	E_LogJsonHide = &file_logjson_proto_extTypes[4]
 )
 
 var File_logjson_proto protoreflect.FileDescriptor
@@ -43,25 +303,94 @@ var file_logjson_proto_rawDesc = []byte{
 	0x0a, 0x0d, 0x6c, 0x6f, 0x67, 0x6a, 0x73, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a,
 	0x20, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
 	0x2f, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x3a, 0x3a, 0x0a, 0x08, 0x6c, 0x6f, 0x67, 0x5f, 0x6a, 0x73, 0x6f, 0x6e, 0x12, 0x1d, 0x2e,
-	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
-	0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xa1, 0x83, 0x06,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6c, 0x6f, 0x67, 0x4a, 0x73, 0x6f, 0x6e, 0x42, 0x24, 0x5a,
-	0x22, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x65, 0x74, 0x68, 0x61,
-	0x6e, 0x76, 0x63, 0x2f, 0x6c, 0x6f, 0x67, 0x6a, 0x73, 0x6f, 0x6e, 0x3b, 0x6c, 0x6f, 0x67, 0x6a,
-	0x73, 0x6f, 0x6e,
+	0x6f, 0x22, 0x80, 0x03, 0x0a, 0x0b, 0x4c, 0x6f, 0x67, 0x4a, 0x73, 0x6f, 0x6e, 0x52, 0x75, 0x6c,
+	0x65, 0x12, 0x2b, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x13, 0x2e, 0x4c, 0x6f, 0x67, 0x4a, 0x73, 0x6f, 0x6e, 0x52, 0x75, 0x6c, 0x65, 0x2e,
+	0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1f,
+	0x0a, 0x0b, 0x6d, 0x61, 0x73, 0x6b, 0x5f, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x0a, 0x6d, 0x61, 0x73, 0x6b, 0x50, 0x72, 0x65, 0x66, 0x69, 0x78, 0x12,
+	0x1f, 0x0a, 0x0b, 0x6d, 0x61, 0x73, 0x6b, 0x5f, 0x73, 0x75, 0x66, 0x66, 0x69, 0x78, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x6d, 0x61, 0x73, 0x6b, 0x53, 0x75, 0x66, 0x66, 0x69, 0x78,
+	0x12, 0x41, 0x0a, 0x0e, 0x68, 0x61, 0x73, 0x68, 0x5f, 0x61, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74,
+	0x68, 0x6d, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1a, 0x2e, 0x4c, 0x6f, 0x67, 0x4a, 0x73,
+	0x6f, 0x6e, 0x52, 0x75, 0x6c, 0x65, 0x2e, 0x48, 0x61, 0x73, 0x68, 0x41, 0x6c, 0x67, 0x6f, 0x72,
+	0x69, 0x74, 0x68, 0x6d, 0x52, 0x0d, 0x68, 0x61, 0x73, 0x68, 0x41, 0x6c, 0x67, 0x6f, 0x72, 0x69,
+	0x74, 0x68, 0x6d, 0x12, 0x26, 0x0a, 0x0e, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x69, 0x66, 0x69, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x63, 0x6c, 0x61,
+	0x73, 0x73, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x53, 0x0a, 0x06, 0x41,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x12, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f,
+	0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0f, 0x0a,
+	0x0b, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x4f, 0x4d, 0x49, 0x54, 0x10, 0x01, 0x12, 0x0f,
+	0x0a, 0x0b, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x48, 0x41, 0x53, 0x48, 0x10, 0x02, 0x12,
+	0x0f, 0x0a, 0x0b, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x4d, 0x41, 0x53, 0x4b, 0x10, 0x03,
+	0x22, 0x42, 0x0a, 0x0d, 0x48, 0x61, 0x73, 0x68, 0x41, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74, 0x68,
+	0x6d, 0x12, 0x16, 0x0a, 0x12, 0x48, 0x41, 0x53, 0x48, 0x5f, 0x41, 0x4c, 0x47, 0x4f, 0x52, 0x49,
+	0x54, 0x48, 0x4d, 0x5f, 0x4d, 0x44, 0x35, 0x10, 0x00, 0x12, 0x19, 0x0a, 0x15, 0x48, 0x41, 0x53,
+	0x48, 0x5f, 0x41, 0x4c, 0x47, 0x4f, 0x52, 0x49, 0x54, 0x48, 0x4d, 0x5f, 0x53, 0x48, 0x41, 0x32,
+	0x35, 0x36, 0x10, 0x01, 0x3a, 0x3a, 0x0a, 0x08, 0x6c, 0x6f, 0x67, 0x5f, 0x6a, 0x73, 0x6f, 0x6e,
+	0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18,
+	0xa1, 0x83, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6c, 0x6f, 0x67, 0x4a, 0x73, 0x6f, 0x6e,
+	0x3a, 0x51, 0x0a, 0x0d, 0x6c, 0x6f, 0x67, 0x5f, 0x6a, 0x73, 0x6f, 0x6e, 0x5f, 0x72, 0x75, 0x6c,
+	0x65, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x18, 0xa2, 0x83, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x4c, 0x6f, 0x67, 0x4a, 0x73,
+	0x6f, 0x6e, 0x52, 0x75, 0x6c, 0x65, 0x52, 0x0b, 0x6c, 0x6f, 0x67, 0x4a, 0x73, 0x6f, 0x6e, 0x52,
+	0x75, 0x6c, 0x65, 0x3a, 0x4b, 0x0a, 0x10, 0x6c, 0x6f, 0x67, 0x5f, 0x6a, 0x73, 0x6f, 0x6e, 0x5f,
+	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1f, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xa1, 0x83, 0x06, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0e, 0x6c, 0x6f, 0x67, 0x4a, 0x73, 0x6f, 0x6e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x3a, 0x4b, 0x0a, 0x10, 0x6c, 0x6f, 0x67, 0x5f, 0x6a, 0x73, 0x6f, 0x6e, 0x5f, 0x64, 0x65, 0x66,
+	0x61, 0x75, 0x6c, 0x74, 0x12, 0x1f, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x4f, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xa2, 0x83, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x6c,
+	0x6f, 0x67, 0x4a, 0x73, 0x6f, 0x6e, 0x44, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x3a, 0x47, 0x0a,
+	0x0d, 0x6c, 0x6f, 0x67, 0x5f, 0x6a, 0x73, 0x6f, 0x6e, 0x5f, 0x68, 0x69, 0x64, 0x65, 0x12, 0x21,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x45, 0x6e, 0x75, 0x6d, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x18, 0xa1, 0x83, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x6c, 0x6f, 0x67, 0x4a, 0x73,
+	0x6f, 0x6e, 0x48, 0x69, 0x64, 0x65, 0x42, 0x24, 0x5a, 0x22, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
+	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x65, 0x74, 0x68, 0x61, 0x6e, 0x76, 0x63, 0x2f, 0x6c, 0x6f, 0x67,
+	0x6a, 0x73, 0x6f, 0x6e, 0x3b, 0x6c, 0x6f, 0x67, 0x6a, 0x73, 0x6f, 0x6e,
 }
 
+var (
+	file_logjson_proto_rawDescOnce sync.Once
+	file_logjson_proto_rawDescData = file_logjson_proto_rawDesc
+)
+
+func file_logjson_proto_rawDescGZIP() []byte {
+	file_logjson_proto_rawDescOnce.Do(func() {
+		file_logjson_proto_rawDescData = protoimpl.X.CompressGZIP(file_logjson_proto_rawDescData)
+	})
+	return file_logjson_proto_rawDescData
+}
+
+var file_logjson_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_logjson_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
 var file_logjson_proto_goTypes = []interface{}{
-	(*descriptorpb.FieldOptions)(nil), // 0: google.protobuf.FieldOptions
+	(LogJsonRule_Action)(0),               // 0: LogJsonRule.Action
+	(LogJsonRule_HashAlgorithm)(0),        // 1: LogJsonRule.HashAlgorithm
+	(*LogJsonRule)(nil),                   // 2: LogJsonRule
+	(*descriptorpb.FieldOptions)(nil),     // 3: google.protobuf.FieldOptions
+	(*descriptorpb.MessageOptions)(nil),   // 4: google.protobuf.MessageOptions
+	(*descriptorpb.EnumValueOptions)(nil), // 5: google.protobuf.EnumValueOptions
 }
 var file_logjson_proto_depIdxs = []int32{
-	0, // 0: log_json:extendee -> google.protobuf.FieldOptions
-	1, // [1:1] is the sub-list for method output_type
-	1, // [1:1] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	0, // [0:1] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0, // 0: LogJsonRule.action:type_name -> LogJsonRule.Action
+	1, // 1: LogJsonRule.hash_algorithm:type_name -> LogJsonRule.HashAlgorithm
+	3, // 2: log_json:extendee -> google.protobuf.FieldOptions
+	3, // 3: log_json_rule:extendee -> google.protobuf.FieldOptions
+	4, // 4: log_json_message:extendee -> google.protobuf.MessageOptions
+	4, // 5: log_json_default:extendee -> google.protobuf.MessageOptions
+	5, // 6: log_json_hide:extendee -> google.protobuf.EnumValueOptions
+	2, // 7: log_json_rule:type_name -> LogJsonRule
+	8, // [8:8] is the sub-list for method output_type
+	8, // [8:8] is the sub-list for method input_type
+	7, // [7:8] is the sub-list for extension type_name
+	2, // [2:7] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_logjson_proto_init() }
@@ -69,18 +398,34 @@ func file_logjson_proto_init() {
 	if File_logjson_proto != nil {
 		return
 	}
+	if !protoimpl.UnsafeEnabled {
+		file_logjson_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LogJsonRule); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_logjson_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   0,
-			NumExtensions: 1,
+			NumEnums:      2,
+			NumMessages:   1,
+			NumExtensions: 5,
 			NumServices:   0,
 		},
 		GoTypes:           file_logjson_proto_goTypes,
 		DependencyIndexes: file_logjson_proto_depIdxs,
+		EnumInfos:         file_logjson_proto_enumTypes,
+		MessageInfos:      file_logjson_proto_msgTypes,
 		ExtensionInfos:    file_logjson_proto_extTypes,
 	}.Build()
 	File_logjson_proto = out.File
@@ -0,0 +1,204 @@
+package logjson
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+// SetStrictMapKeys makes maps whose keys are not bools, numbers or strings
+// written as null, and ignores TextMarshaler of keys. By default such keys
+// are converted to strings, see makeMapKeyStringify.
+func (j *LogJson) SetStrictMapKeys(strict bool) {
+	j.updateOptions(func(opts *logJsonOptions) {
+		opts.strictMapKeys = strict
+	})
+}
+
+// SetMapKeyStringer makes the String method of keys preferred to their values
+// when keys are bools, numbers or strings, such as the names of enums.
+func (j *LogJson) SetMapKeyStringer(prefer bool) {
+	j.updateOptions(func(opts *logJsonOptions) {
+		opts.mapKeyStringer = prefer
+	})
+}
+
+type mapKeyStringifyFunc = func(v reflect.Value, state *EncoderState) string
+
+var textMarshalerIntType = reflect.TypeFor[encoding.TextMarshaler]()
+var stringerIntType = reflect.TypeFor[fmt.Stringer]()
+
+// makeMapKeyStringify returns the func converting map keys of type t to
+// strings, it reports false when keys of type t are not supported. Keys are
+// converted by the first of:
+//   - MarshalText of encoding.TextMarshaler.
+//   - String of fmt.Stringer, when SetMapKeyStringer is set.
+//   - The value of bools, numbers and strings.
+//   - String of fmt.Stringer.
+//   - The key pointed to by pointers, or "null".
+//   - The key of the dynamic type of interfaces, or "null".
+//   - The compact JSON of other keys, such as structs and arrays, written
+//     with the log rules of the call.
+//
+// Only bools, numbers and strings are supported with SetStrictMapKeys.
+func (j *LogJson) makeMapKeyStringify(t reflect.Type) (mapKeyStringifyFunc, bool) {
+	opts := j.getOptions()
+	if basic, ok := generateMarshalToStringFunc(t, opts.decimals()); ok {
+		stringify := func(v reflect.Value, state *EncoderState) string {
+			return basic(v)
+		}
+		if !opts.strictMapKeys && opts.mapKeyStringer {
+			stringify = makeMethodKeyStringify(t, stringerIntType, stringify)
+		}
+		if !opts.strictMapKeys {
+			stringify = makeMethodKeyStringify(t, textMarshalerIntType, stringify)
+		}
+		return stringify, true
+	}
+	if opts.strictMapKeys {
+		return nil, false
+	}
+	var stringify mapKeyStringifyFunc
+	switch t.Kind() {
+	case reflect.Pointer:
+		var once sync.Once
+		var elemStringify mapKeyStringifyFunc
+		init := func() {
+			elemStringify, _ = j.makeMapKeyStringify(t.Elem())
+		}
+		stringify = func(v reflect.Value, state *EncoderState) string {
+			if v.IsNil() {
+				return "null"
+			}
+			once.Do(init)
+			return elemStringify(v.Elem(), state)
+		}
+	case reflect.Interface:
+		stringify = func(v reflect.Value, state *EncoderState) string {
+			if v.IsNil() {
+				return "null"
+			}
+			v = v.Elem()
+			return j.getMapKeyStringify(v.Type())(v, state)
+		}
+	default:
+		stringify = j.marshalMapKeyJSON
+	}
+	stringify = makeMethodKeyStringify(t, stringerIntType, stringify)
+	stringify = makeMethodKeyStringify(t, textMarshalerIntType, stringify)
+	return stringify, true
+}
+
+// getMapKeyStringify returns the cached func converting keys of type t, which
+// is not an interface.
+func (j *LogJson) getMapKeyStringify(t reflect.Type) mapKeyStringifyFunc {
+	if tmp, ok := j.mapKeyFuncs.Load(t); ok {
+		return tmp.(mapKeyStringifyFunc)
+	}
+	stringify, ok := j.makeMapKeyStringify(t)
+	if !ok {
+		stringify = func(v reflect.Value, state *EncoderState) string {
+			return "null"
+		}
+	}
+	j.mapKeyFuncs.Store(t, stringify)
+	return stringify
+}
+
+// makeMethodKeyStringify returns the func converting keys of type t by the
+// method of intType, next is used when t does not implement intType or the
+// method fails. Methods with pointer receivers are called on a copy.
+func makeMethodKeyStringify(t reflect.Type, intType reflect.Type, next mapKeyStringifyFunc) mapKeyStringifyFunc {
+	addr := false
+	if !t.Implements(intType) {
+		if t.Kind() == reflect.Pointer || t.Kind() == reflect.Interface || !reflect.PointerTo(t).Implements(intType) {
+			return next
+		}
+		addr = true
+	}
+	return func(v reflect.Value, state *EncoderState) string {
+		if (v.Kind() == reflect.Pointer && v.IsNil()) || !v.CanInterface() {
+			return next(v, state)
+		}
+		m := v
+		if addr {
+			m = reflect.New(t)
+			m.Elem().Set(v)
+		}
+		if intType == textMarshalerIntType {
+			if text, err := m.Interface().(encoding.TextMarshaler).MarshalText(); err == nil {
+				return string(text)
+			}
+			return next(v, state)
+		}
+		return m.Interface().(fmt.Stringer).String()
+	}
+}
+
+// marshalMapKeyJSON returns the compact JSON of v written with the options and
+// log rules of the call.
+func (j *LogJson) marshalMapKeyJSON(v reflect.Value, state *EncoderState) string {
+	keyState, buf := getPooledEncoderState()
+	defer encoderStatePool.Put(keyState)
+	keyState.opts, keyState.hasOptions = state.opts, true
+	keyState.logJson = j
+	j.getHandlerItem(v.Type()).marshal(v, keyState)
+	return string(removeNewline(buf.Bytes()))
+}
+
+// mapKeysUnique reports whether different keys of type t are always converted
+// to different strings by makeMapKeyStringify.
+func (j *LogJson) mapKeysUnique(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+	default:
+		return false
+	}
+	opts := j.getOptions()
+	if opts.strictMapKeys {
+		return true
+	}
+	ptr := reflect.PointerTo(t)
+	if t.Implements(textMarshalerIntType) || ptr.Implements(textMarshalerIntType) {
+		return false
+	}
+	return !opts.mapKeyStringer || !(t.Implements(stringerIntType) || ptr.Implements(stringerIntType))
+}
+
+// mapKeyNames are the names written for the keys of a map whose keys may be
+// converted to the same string, such as 1 and "1" of map[any]int.
+type mapKeyNames map[string]struct{}
+
+// unique returns key, or key with the first free suffix "#2", "#3" and so on
+// when key is already written.
+func (names mapKeyNames) unique(key string) string {
+	if _, ok := names[key]; ok {
+		for n := 2; ; n++ {
+			name := key + "#" + strconv.Itoa(n)
+			if _, ok := names[name]; !ok {
+				key = name
+				break
+			}
+		}
+	}
+	names[key] = struct{}{}
+	return key
+}
+
+// writeMapEntry writes a map entry named key, the value is skipped when the
+// name can not be written.
+func writeMapEntry(key string, names mapKeyNames, value reflect.Value, valueHandlerItem *handlerItem, state *EncoderState) {
+	if names != nil {
+		key = names.unique(key)
+	}
+	if err := state.Encoder.WriteToken(jsontext.String(key)); err != nil {
+		return
+	}
+	valueHandlerItem.marshal(value, state)
+}
@@ -1,6 +1,8 @@
 package logjson
 
 import (
+	"context"
+
 	"github.com/go-json-experiment/json/jsontext"
 )
 
@@ -8,6 +10,33 @@ func Marshal(in any) []byte {
 	return DefaultLogJson().Marshal(in)
 }
 
+func MarshalContext(ctx context.Context, in any) []byte {
+	return DefaultLogJson().MarshalContext(ctx, in)
+}
+
 type LogMarshaler interface {
 	MarshalLogJSON(*jsontext.Encoder)
 }
+
+// StateLogMarshaler is like LogMarshaler, but receives the state of the call,
+// so sub-values can be marshaled by EncoderState.MarshalValue with the rules,
+// options and cycle detection of the call. It is preferred to LogMarshaler.
+type StateLogMarshaler interface {
+	MarshalLogJSONState(*EncoderState)
+}
+
+// LogRulesProvider is implemented by types declaring the rules of their own
+// fields, the rules apply wherever the type appears. Keys are field names as
+// logged, the "*" key is the rule of the fields without their own key. The
+// method is called once on the zero value of the type.
+type LogRulesProvider interface {
+	LogJSONRules() map[string]LogRule
+}
+
+// DecimalMarshaler is implemented by decimal types written as exact JSON
+// numbers. LogDecimal returns the decimal text, such as "12.50", text which
+// is not a JSON number is written as a string. See AddDecimalType for types
+// of other packages.
+type DecimalMarshaler interface {
+	LogDecimal() string
+}
@@ -0,0 +1,48 @@
+package logjson
+
+import "context"
+
+// MarshalOption changes the behavior of a single marshal call, see
+// LogJson.MarshalWith and EncoderState.SetOptions.
+type MarshalOption func(opts *marshalOptions)
+
+type marshalOptions struct {
+	ctx            context.Context
+	ignoreLogRules bool
+	logRules       map[string]*logRuleConf
+	maxDepth       int
+}
+
+// WithContext sets the context which context aware rules such as LogRuleIf
+// are evaluated against.
+func WithContext(ctx context.Context) MarshalOption {
+	return func(opts *marshalOptions) {
+		opts.ctx = ctx
+	}
+}
+
+// WithoutLogRules disables all rules, including log tags and proto options,
+// for the call.
+func WithoutLogRules() MarshalOption {
+	return func(opts *marshalOptions) {
+		opts.ignoreLogRules = true
+	}
+}
+
+// WithLogRule overrides the rule for fields named key for the call, the rules
+// of types and messages use TypeRuleKey and MessageRuleKey as key.
+func WithLogRule(key string, rule LogRule) MarshalOption {
+	return func(opts *marshalOptions) {
+		if opts.logRules == nil {
+			opts.logRules = make(map[string]*logRuleConf)
+		}
+		opts.logRules[key] = newLogRuleConf(rule)
+	}
+}
+
+// WithMaxDepth writes null for objects and arrays nested deeper than depth.
+func WithMaxDepth(depth int) MarshalOption {
+	return func(opts *marshalOptions) {
+		opts.maxDepth = depth
+	}
+}
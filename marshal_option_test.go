@@ -0,0 +1,45 @@
+package logjson
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogJson_MarshalWith(t *testing.T) {
+	type Abc struct {
+		Name     string
+		BankCard string `log:"md5"`
+	}
+	abc := Abc{Name: "hello", BankCard: "hello"}
+	j := NewLogJson()
+	require.Equal(t, `{"Name":"hello","BankCard":"hello"}`, string(j.MarshalWith(abc, WithoutLogRules())))
+	require.Equal(t, `{"BankCard":"5;5d41402abc4b2a76b9719d911017c592"}`,
+		string(j.MarshalWith(abc, WithLogRule("Name", LogRuleOmit()))))
+	require.Equal(t, `{"Name":"hello","BankCard":"5;5d41402abc4b2a76b9719d911017c592"}`, string(j.Marshal(abc)))
+}
+
+func TestLogJson_MarshalWithMaxDepth(t *testing.T) {
+	type Abc struct {
+		P *Abc
+	}
+	abc := &Abc{P: &Abc{P: &Abc{}}}
+	require.Equal(t, `{"P":{"P":null}}`, string(NewLogJson().MarshalWith(abc, WithMaxDepth(2))))
+}
+
+type testDebugKey struct{}
+
+func TestLogJson_MarshalContext(t *testing.T) {
+	type Abc struct {
+		Name string
+	}
+	j := NewLogJson()
+	j.AddLogRule("Name", LogRuleIf(func(ctx context.Context) bool {
+		return ctx.Value(testDebugKey{}) == nil
+	}, LogRuleMd5()))
+	abc := Abc{Name: "hello"}
+	require.Equal(t, `{"Name":"5;5d41402abc4b2a76b9719d911017c592"}`, string(j.Marshal(abc)))
+	ctx := context.WithValue(context.Background(), testDebugKey{}, true)
+	require.Equal(t, `{"Name":"hello"}`, string(j.MarshalContext(ctx, abc)))
+}
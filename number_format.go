@@ -0,0 +1,116 @@
+package logjson
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+// maxSafeInt is 2^53, the integers beyond ±maxSafeInt may not be exact in
+// float64 numbers of JavaScript.
+const maxSafeInt = 1 << 53
+
+// SetLargeIntAsString writes integers outside ±2^53 as strings, so log viewers
+// reading numbers as float64, such as the ones in JavaScript, keep them exact.
+func (j *LogJson) SetLargeIntAsString(asString bool) {
+	j.updateOptions(func(opts *logJsonOptions) {
+		opts.largeIntAsString = asString
+	})
+}
+
+// SetFloatDecimals writes floats with n digits after the decimal point, such
+// as 12.50 with 2. A negative n restores the shortest representation, which
+// is the default. Float map keys are formatted the same way. Proto messages
+// keep the protojson format.
+func (j *LogJson) SetFloatDecimals(n int) {
+	j.updateOptions(func(opts *logJsonOptions) {
+		opts.floatDecimals = n
+		opts.fixedFloats = n >= 0
+	})
+}
+
+// decimals returns the digits after the decimal point of floats, or -1 for
+// the shortest representation.
+func (opts logJsonOptions) decimals() int {
+	if !opts.fixedFloats {
+		return -1
+	}
+	return opts.floatDecimals
+}
+
+func writeInt(enc *jsontext.Encoder, v int64, largeAsString bool) {
+	if largeAsString && (v > maxSafeInt || v < -maxSafeInt) {
+		enc.WriteToken(jsontext.String(strconv.FormatInt(v, 10)))
+		return
+	}
+	enc.WriteToken(jsontext.Int(v))
+}
+
+func writeUint(enc *jsontext.Encoder, v uint64, largeAsString bool) {
+	if largeAsString && v > maxSafeInt {
+		enc.WriteToken(jsontext.String(strconv.FormatUint(v, 10)))
+		return
+	}
+	enc.WriteToken(jsontext.Uint(v))
+}
+
+// writeFloat writes f with the precision of bits and decimals digits after
+// the decimal point, or the shortest representation when decimals is
+// negative. NaN and infinities are written like jsontext.Float does.
+func writeFloat(enc *jsontext.Encoder, f float64, bits int, decimals int) {
+	if math.IsNaN(f) || math.IsInf(f, 0) || bits == 64 && decimals < 0 {
+		enc.WriteToken(jsontext.Float(f))
+		return
+	}
+	enc.WriteValue(appendFormattedFloat(nil, f, bits, decimals))
+}
+
+func appendFormattedFloat(b []byte, f float64, bits int, decimals int) []byte {
+	if decimals < 0 {
+		return appendFloat(b, f, bits)
+	}
+	return strconv.AppendFloat(b, f, 'f', decimals, bits)
+}
+
+func (j *LogJson) makeFloatHandlerItem(t reflect.Type) *handlerItem {
+	bits := t.Bits()
+	decimals := j.getOptions().decimals()
+	item := &handlerItem{}
+	if bits == 64 && decimals < 0 {
+		item.marshal = func(v reflect.Value, state *EncoderState) {
+			state.Encoder.WriteToken(jsontext.Float(v.Float()))
+		}
+		return item
+	}
+	item.marshal = func(v reflect.Value, state *EncoderState) {
+		writeFloat(state.Encoder, v.Float(), bits, decimals)
+	}
+	return item
+}
+
+// WriteInt writes v like the LogJson of the state writes int64 values, it is
+// used by the code generated by logjsongen.
+func (state *EncoderState) WriteInt(v int64) {
+	if v <= maxSafeInt && v >= -maxSafeInt {
+		state.Encoder.WriteToken(jsontext.Int(v))
+		return
+	}
+	writeInt(state.Encoder, v, state.LogJson().getOptions().largeIntAsString)
+}
+
+// WriteUint writes v like the LogJson of the state writes uint64 values.
+func (state *EncoderState) WriteUint(v uint64) {
+	if v <= maxSafeInt {
+		state.Encoder.WriteToken(jsontext.Uint(v))
+		return
+	}
+	writeUint(state.Encoder, v, state.LogJson().getOptions().largeIntAsString)
+}
+
+// WriteFloat writes f like the LogJson of the state writes floats of bits,
+// unlike the WriteFloat function it applies SetFloatDecimals.
+func (state *EncoderState) WriteFloat(f float64, bits int) {
+	writeFloat(state.Encoder, f, bits, state.LogJson().getOptions().decimals())
+}
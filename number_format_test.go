@@ -0,0 +1,60 @@
+package logjson
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-json-experiment/json/jsontext"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogJson_LargeIntAsString(t *testing.T) {
+	type Abc struct {
+		Id    int64
+		Neg   int64
+		Small int64
+		Max   uint64
+		Safe  uint64
+	}
+	abc := Abc{Id: 1<<53 + 1, Neg: -(1<<53 + 1), Small: 1 << 53, Max: math.MaxUint64, Safe: 1 << 53}
+	j := NewLogJson()
+	require.Equal(t, `{"Id":9007199254740993,"Neg":-9007199254740993,"Small":9007199254740992,"Max":18446744073709551615,"Safe":9007199254740992}`,
+		string(j.Marshal(abc)))
+	j.SetLargeIntAsString(true)
+	require.Equal(t, `{"Id":"9007199254740993","Neg":"-9007199254740993","Small":9007199254740992,"Max":"18446744073709551615","Safe":9007199254740992}`,
+		string(j.Marshal(abc)))
+}
+
+func TestLogJson_Float(t *testing.T) {
+	type Abc struct {
+		F32  float32
+		F64  float64
+		Big  float32
+		Keys map[float32]int
+	}
+	abc := Abc{F32: 0.1, F64: 0.1, Big: 1e21, Keys: map[float32]int{0.1: 1}}
+	j := NewLogJson()
+	require.Equal(t, `{"F32":0.1,"F64":0.1,"Big":1e+21,"Keys":{"0.1":1}}`, string(j.Marshal(abc)))
+	j.SetFloatDecimals(2)
+	require.Equal(t, `{"F32":0.10,"F64":0.10,"Big":1000000020040877342720.00,"Keys":{"0.10":1}}`, string(j.Marshal(abc)))
+	j.SetFloatDecimals(-1)
+	require.Equal(t, `{"F32":0.1,"F64":0.1,"Big":1e+21,"Keys":{"0.1":1}}`, string(j.Marshal(abc)))
+}
+
+type testNumberMarshaler struct{}
+
+func (testNumberMarshaler) MarshalLogJSONState(state *EncoderState) {
+	state.WriteToken(jsontext.ArrayStart)
+	state.WriteInt(1<<53 + 1)
+	state.WriteUint(math.MaxUint64)
+	state.WriteFloat(0.1, 32)
+	state.WriteToken(jsontext.ArrayEnd)
+}
+
+func TestEncoderState_WriteNumbers(t *testing.T) {
+	j := NewLogJson()
+	require.Equal(t, `[9007199254740993,18446744073709551615,0.1]`, string(j.Marshal(testNumberMarshaler{})))
+	j.SetLargeIntAsString(true)
+	j.SetFloatDecimals(2)
+	require.Equal(t, `["9007199254740993","18446744073709551615",0.10]`, string(j.Marshal(testNumberMarshaler{})))
+}
@@ -0,0 +1,70 @@
+package logjson
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+// SetDescribeOpaqueKinds makes values of kinds without JSON representations
+// written as strings describing them instead of null:
+//   - Channels as "chan int(len=3,cap=5)".
+//   - Funcs as their names, such as "main.handle".
+//   - Complex numbers as "1+2i".
+//   - Uintptrs and unsafe pointers as hex addresses, such as "0xc000012345".
+//
+// Nil channels, funcs and unsafe pointers are still written as null.
+func (j *LogJson) SetDescribeOpaqueKinds(describe bool) {
+	j.updateOptions(func(opts *logJsonOptions) {
+		opts.describeOpaqueKinds = describe
+	})
+}
+
+// makeOpaqueKindHandlerItem creates the handler describing values of t, it
+// returns nil when t is not one of the kinds of SetDescribeOpaqueKinds.
+func makeOpaqueKindHandlerItem(t reflect.Type) *handlerItem {
+	var describe func(v reflect.Value) string
+	switch t.Kind() {
+	case reflect.Chan:
+		describe = func(v reflect.Value) string {
+			return fmt.Sprintf("%s(len=%d,cap=%d)", t, v.Len(), v.Cap())
+		}
+	case reflect.Func:
+		describe = func(v reflect.Value) string {
+			if f := runtime.FuncForPC(v.Pointer()); f != nil {
+				return f.Name()
+			}
+			return t.String()
+		}
+	case reflect.Complex64, reflect.Complex128:
+		bits := t.Bits()
+		describe = func(v reflect.Value) string {
+			s := strconv.FormatComplex(v.Complex(), 'g', -1, bits)
+			return strings.TrimSuffix(strings.TrimPrefix(s, "("), ")")
+		}
+	case reflect.Uintptr:
+		describe = func(v reflect.Value) string {
+			return "0x" + strconv.FormatUint(v.Uint(), 16)
+		}
+	case reflect.UnsafePointer:
+		describe = func(v reflect.Value) string {
+			return "0x" + strconv.FormatUint(uint64(v.Pointer()), 16)
+		}
+	default:
+		return nil
+	}
+	nullable := t.Kind() == reflect.Chan || t.Kind() == reflect.Func || t.Kind() == reflect.UnsafePointer
+	return &handlerItem{
+		marshal: func(v reflect.Value, state *EncoderState) {
+			if nullable && v.IsNil() {
+				state.Encoder.WriteToken(jsontext.Null)
+				return
+			}
+			state.Encoder.WriteToken(jsontext.String(describe(v)))
+		},
+	}
+}
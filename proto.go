@@ -0,0 +1,565 @@
+package logjson
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-json-experiment/json/jsontext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+func (j *LogJson) makeProtoMessageHandlerItem() *handlerItem {
+	return &handlerItem{
+		marshal: func(v reflect.Value, state *EncoderState) {
+			msg, _ := v.Interface().(proto.Message)
+			if msg == nil {
+				state.Encoder.WriteToken(jsontext.Null)
+				return
+			}
+			j.marshalProtoMessage(msg.ProtoReflect(), state)
+		},
+	}
+}
+
+// makeReflectProtoMessageHandlerItem creates the handler of MarshalReflect for
+// proto messages, the cycles of the message itself are detected by the handler
+// of its generated marshaler, see withCycleDetection.
+func (j *LogJson) makeReflectProtoMessageHandlerItem() *handlerItem {
+	return &handlerItem{
+		marshal: func(v reflect.Value, state *EncoderState) {
+			msg, _ := v.Interface().(proto.Message)
+			if msg == nil {
+				state.Encoder.WriteToken(jsontext.Null)
+				return
+			}
+			j.marshalProtoMessageWith(msg.ProtoReflect(), state, false)
+		},
+	}
+}
+
+// protoMessageItem is the handler of a message descriptor, it writes the
+// message like protojson does and applies rules to every field.
+type protoMessageItem struct {
+	// ruleKey is the key of the message in per-call rules and grants, see
+	// MessageRuleKey.
+	ruleKey string
+	// conf is the log_json_message rule of the message.
+	conf   *logRuleConf
+	fields []protoField
+	// cycleDepth is the depth from which cycles are detected, see
+	// SetCycleDetectionDepth.
+	cycleDepth int
+}
+
+type protoField struct {
+	desc protoreflect.FieldDescriptor
+	name string
+	conf *logRuleConf
+	// goType is the Go type rules see for singular scalar fields, it is nil
+	// for other fields and only omit and the rules of protectsStrings apply to
+	// them.
+	goType reflect.Type
+	// ruleItem applies conf, it is nil when conf does not change the output.
+	ruleItem *handlerItem
+	// msgConf is the log_json_message rule of singular message fields.
+	msgConf *logRuleConf
+	grants  *grantRegistry
+}
+
+func (j *LogJson) getProtoMessageItem(desc protoreflect.MessageDescriptor) *protoMessageItem {
+	if tmp, ok := j.protoItems.Load(desc); ok {
+		return tmp.(*protoMessageItem)
+	}
+	item := j.newProtoMessageItem(desc)
+	if existItem, loaded := j.protoItems.LoadOrStore(desc, item); loaded {
+		return existItem.(*protoMessageItem)
+	}
+	return item
+}
+
+func (j *LogJson) newProtoMessageItem(desc protoreflect.MessageDescriptor) *protoMessageItem {
+	opts := j.getOptions()
+	item := &protoMessageItem{
+		ruleKey:    MessageRuleKey(desc.FullName()),
+		cycleDepth: opts.cycleDetectionDepth(),
+	}
+	if !j.ignoreFieldRules {
+		item.conf = newLogRuleConfFromStr(getProtoMessageLogJsonValue(desc))
+	}
+	fields := desc.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		f := protoField{
+			desc:   fd,
+			name:   string(fd.Name()),
+			goType: protoFieldGoType(fd),
+			grants: j.grants,
+		}
+		if opts.protoJsonName {
+			f.name = fd.JSONName()
+		}
+		if !j.ignoreFieldRules {
+			f.conf = getProtoFieldLogRuleConf(fd)
+			if fd.Message() != nil && !fd.IsList() && !fd.IsMap() {
+				f.msgConf = newLogRuleConfFromStr(getProtoMessageLogJsonValue(fd.Message()))
+			}
+		}
+		if f.conf == nil {
+			f.conf = j.getLogRule(f.name)
+		}
+		f.ruleItem = f.getRuleItem(f.conf)
+		item.fields = append(item.fields, f)
+	}
+	return item
+}
+
+// getRuleItem returns the handler applying conf to the values of f, or nil
+// when conf does not change them. Rules such as tokenize write the values
+// without goType as null.
+func (f *protoField) getRuleItem(conf *logRuleConf) *handlerItem {
+	if conf == nil {
+		return nil
+	}
+	if f.goType == nil {
+		if conf.protectsStrings() {
+			return nullHandlerItem
+		}
+		return nil
+	}
+	return conf.getHandlerItem(f.goType, nil)
+}
+
+// MessageRuleKey returns the key of the log_json_message rule of the message
+// named name in per-call rules of WithLogRule and in grants, such as
+// "message:pkg.Order". The prefix keeps it apart from field names.
+func MessageRuleKey(name protoreflect.FullName) string {
+	return "message:" + string(name)
+}
+
+func protoFieldGoType(fd protoreflect.FieldDescriptor) reflect.Type {
+	if fd.IsList() || fd.IsMap() {
+		return nil
+	}
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind, protoreflect.EnumKind:
+		return nil
+	}
+	return reflect.TypeOf(fd.Default().Interface())
+}
+
+func (j *LogJson) marshalProtoMessage(m protoreflect.Message, state *EncoderState) {
+	j.marshalProtoMessageWith(m, state, true)
+}
+
+// marshalProtoMessageWith writes m, detectCycle reports whether m itself is
+// checked for cycles, the messages in its fields are checked anyway.
+func (j *LogJson) marshalProtoMessageWith(m protoreflect.Message, state *EncoderState, detectCycle bool) {
+	if !m.IsValid() || state.exceedMaxDepth() {
+		state.Encoder.WriteToken(jsontext.Null)
+		return
+	}
+	item := j.getProtoMessageItem(m.Descriptor())
+	if conf := j.activeProtoMessageRule(item, state); conf != nil {
+		writeProtoMessageRule(m, conf, state)
+		return
+	}
+	if v := reflect.ValueOf(m.Interface()); detectCycle && v.Kind() == reflect.Pointer && state.Encoder.StackDepth() >= item.cycleDepth {
+		if !state.enterPointer(v) {
+			state.writeCycleRef(v)
+			return
+		}
+		defer state.leavePointer(v)
+	}
+	if marshal, ok := wellKnownProtoMarshalers[m.Descriptor().FullName()]; ok {
+		marshal(j, m, state)
+		return
+	}
+	state.Encoder.WriteToken(jsontext.ObjectStart)
+	j.marshalProtoFields(m, state)
+	state.Encoder.WriteToken(jsontext.ObjectEnd)
+}
+
+// activeProtoMessageRule returns the log_json_message rule of the message of
+// item when it changes the message for the call, such as omit and md5.
+func (j *LogJson) activeProtoMessageRule(item *protoMessageItem, state *EncoderState) *logRuleConf {
+	conf := activeLogRule(state, item.ruleKey, item.conf, j.grants)
+	if conf != nil && (conf.Omit() || conf.hashFunc() != nil) {
+		return conf
+	}
+	return nil
+}
+
+// writeProtoMessageRule writes m with the rule of activeProtoMessageRule, null
+// for omit and the hash of the binary message for hash rules.
+func writeProtoMessageRule(m protoreflect.Message, conf *logRuleConf, state *EncoderState) {
+	if conf.Omit() {
+		state.Encoder.WriteToken(jsontext.Null)
+		return
+	}
+	buf, _ := proto.MarshalOptions{Deterministic: true, AllowPartial: true}.Marshal(m.Interface())
+	state.Encoder.WriteToken(jsontext.String(conf.hashFunc()(string(buf))))
+}
+
+func (j *LogJson) marshalProtoFields(m protoreflect.Message, state *EncoderState) {
+	item := j.getProtoMessageItem(m.Descriptor())
+	for i := range item.fields {
+		f := &item.fields[i]
+		if !m.Has(f.desc) {
+			continue
+		}
+		conf := activeLogRule(state, f.name, f.conf, f.grants)
+		if conf != nil && conf.Omit() {
+			continue
+		}
+		if !f.desc.IsList() && !f.desc.IsMap() && j.isProtoEnumHidden(f.desc, m.Get(f.desc), state) {
+			continue
+		}
+		if f.msgConf != nil {
+			msgConf := activeLogRule(state, MessageRuleKey(f.desc.Message().FullName()), f.msgConf, f.grants)
+			if msgConf != nil && msgConf.Omit() {
+				continue
+			}
+		}
+		state.Encoder.WriteToken(jsontext.String(f.name))
+		ruleItem := f.ruleItem
+		if conf != f.conf {
+			ruleItem = f.getRuleItem(conf)
+		}
+		if conf != nil && ruleItem != nil {
+			ruleItem.marshal(reflect.ValueOf(m.Get(f.desc).Interface()), state)
+			continue
+		}
+		j.marshalProtoFieldValue(f.desc, m.Get(f.desc), state)
+	}
+}
+
+func (j *LogJson) marshalProtoFieldValue(fd protoreflect.FieldDescriptor, v protoreflect.Value, state *EncoderState) {
+	switch {
+	case fd.IsList():
+		list := v.List()
+		state.Encoder.WriteToken(jsontext.ArrayStart)
+		for i := 0; i < list.Len(); i++ {
+			if j.isProtoEnumHidden(fd, list.Get(i), state) {
+				continue
+			}
+			j.marshalProtoSingular(fd, list.Get(i), state)
+		}
+		state.Encoder.WriteToken(jsontext.ArrayEnd)
+	case fd.IsMap():
+		j.marshalProtoMap(fd, v.Map(), state)
+	default:
+		j.marshalProtoSingular(fd, v, state)
+	}
+}
+
+func (j *LogJson) marshalProtoMap(fd protoreflect.FieldDescriptor, m protoreflect.Map, state *EncoderState) {
+	keys := make([]protoreflect.MapKey, 0, m.Len())
+	m.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, key)
+		return true
+	})
+	sort.Slice(keys, func(a, b int) bool {
+		return protoMapKeyLess(keys[a], keys[b])
+	})
+	state.Encoder.WriteToken(jsontext.ObjectStart)
+	for _, key := range keys {
+		if j.isProtoEnumHidden(fd.MapValue(), m.Get(key), state) {
+			continue
+		}
+		state.Encoder.WriteToken(jsontext.String(key.String()))
+		j.marshalProtoSingular(fd.MapValue(), m.Get(key), state)
+	}
+	state.Encoder.WriteToken(jsontext.ObjectEnd)
+}
+
+func protoMapKeyLess(a, b protoreflect.MapKey) bool {
+	switch av := a.Interface().(type) {
+	case bool:
+		return !av && b.Bool()
+	case int32, int64:
+		return a.Int() < b.Int()
+	case uint32, uint64:
+		return a.Uint() < b.Uint()
+	}
+	return a.String() < b.String()
+}
+
+func (j *LogJson) marshalProtoSingular(fd protoreflect.FieldDescriptor, v protoreflect.Value, state *EncoderState) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		state.Encoder.WriteToken(jsontext.Bool(v.Bool()))
+	case protoreflect.StringKind:
+		state.Encoder.WriteToken(jsontext.String(v.String()))
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		state.Encoder.WriteToken(jsontext.Int(v.Int()))
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		state.Encoder.WriteToken(jsontext.Uint(v.Uint()))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		state.Encoder.WriteToken(jsontext.String(strconv.FormatInt(v.Int(), 10)))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		state.Encoder.WriteToken(jsontext.String(strconv.FormatUint(v.Uint(), 10)))
+	case protoreflect.FloatKind:
+		WriteFloat(state.Encoder, v.Float(), 32)
+	case protoreflect.DoubleKind:
+		WriteFloat(state.Encoder, v.Float(), 64)
+	case protoreflect.BytesKind:
+		state.Encoder.WriteToken(jsontext.String(base64.StdEncoding.EncodeToString(v.Bytes())))
+	case protoreflect.EnumKind:
+		j.marshalProtoEnum(fd.Enum(), v.Enum(), state)
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		j.marshalProtoMessage(v.Message(), state)
+	default:
+		state.Encoder.WriteToken(jsontext.Null)
+	}
+}
+
+func (j *LogJson) marshalProtoEnum(desc protoreflect.EnumDescriptor, n protoreflect.EnumNumber, state *EncoderState) {
+	if desc.FullName() == "google.protobuf.NullValue" {
+		state.Encoder.WriteToken(jsontext.Null)
+		return
+	}
+	if value := desc.Values().ByNumber(n); value != nil {
+		state.Encoder.WriteToken(jsontext.String(string(value.Name())))
+		return
+	}
+	state.Encoder.WriteToken(jsontext.Int(int64(n)))
+}
+
+var wellKnownProtoMarshalers map[protoreflect.FullName]func(j *LogJson, m protoreflect.Message, state *EncoderState)
+
+func init() {
+	wellKnownProtoMarshalers = map[protoreflect.FullName]func(j *LogJson, m protoreflect.Message, state *EncoderState){
+		"google.protobuf.Any":         (*LogJson).marshalProtoAny,
+		"google.protobuf.Timestamp":   (*LogJson).marshalProtoTimestamp,
+		"google.protobuf.Duration":    (*LogJson).marshalProtoDuration,
+		"google.protobuf.Struct":      (*LogJson).marshalProtoStruct,
+		"google.protobuf.ListValue":   (*LogJson).marshalProtoListValue,
+		"google.protobuf.Value":       (*LogJson).marshalProtoValue,
+		"google.protobuf.FieldMask":   (*LogJson).marshalProtoFieldMask,
+		"google.protobuf.Empty":       (*LogJson).marshalProtoEmpty,
+		"google.protobuf.BoolValue":   (*LogJson).marshalProtoWrapper,
+		"google.protobuf.Int32Value":  (*LogJson).marshalProtoWrapper,
+		"google.protobuf.Int64Value":  (*LogJson).marshalProtoWrapper,
+		"google.protobuf.UInt32Value": (*LogJson).marshalProtoWrapper,
+		"google.protobuf.UInt64Value": (*LogJson).marshalProtoWrapper,
+		"google.protobuf.FloatValue":  (*LogJson).marshalProtoWrapper,
+		"google.protobuf.DoubleValue": (*LogJson).marshalProtoWrapper,
+		"google.protobuf.StringValue": (*LogJson).marshalProtoWrapper,
+		"google.protobuf.BytesValue":  (*LogJson).marshalProtoWrapper,
+	}
+}
+
+// marshalProtoAny writes the message in value with an additional @type member,
+// well known types are written in a value member. Messages which can not be
+// resolved from protoregistry.GlobalTypes are written with their raw bytes.
+func (j *LogJson) marshalProtoAny(m protoreflect.Message, state *EncoderState) {
+	fields := m.Descriptor().Fields()
+	typeUrl := m.Get(fields.ByNumber(1)).String()
+	value := m.Get(fields.ByNumber(2)).Bytes()
+	state.Encoder.WriteToken(jsontext.ObjectStart)
+	state.Encoder.WriteToken(jsontext.String("@type"))
+	state.Encoder.WriteToken(jsontext.String(typeUrl))
+	defer state.Encoder.WriteToken(jsontext.ObjectEnd)
+	mt, err := protoregistry.GlobalTypes.FindMessageByURL(typeUrl)
+	var inner protoreflect.Message
+	if err == nil {
+		inner = mt.New()
+		err = proto.UnmarshalOptions{AllowPartial: true}.Unmarshal(value, inner.Interface())
+	}
+	if err != nil {
+		state.Encoder.WriteToken(jsontext.String("value"))
+		state.Encoder.WriteToken(jsontext.String(base64.StdEncoding.EncodeToString(value)))
+		return
+	}
+	if _, ok := wellKnownProtoMarshalers[inner.Descriptor().FullName()]; ok {
+		state.Encoder.WriteToken(jsontext.String("value"))
+		j.marshalProtoMessage(inner, state)
+		return
+	}
+	// the message redacted by its log_json_message rule is the value.
+	if conf := j.activeProtoMessageRule(j.getProtoMessageItem(inner.Descriptor()), state); conf != nil {
+		state.Encoder.WriteToken(jsontext.String("value"))
+		writeProtoMessageRule(inner, conf, state)
+		return
+	}
+	j.marshalProtoFields(inner, state)
+}
+
+func (j *LogJson) marshalProtoTimestamp(m protoreflect.Message, state *EncoderState) {
+	fields := m.Descriptor().Fields()
+	secs := m.Get(fields.ByNumber(1)).Int()
+	nanos := m.Get(fields.ByNumber(2)).Int()
+	s := time.Unix(secs, nanos).UTC().Format("2006-01-02T15:04:05.000000000")
+	s = trimProtoNanos(s)
+	state.Encoder.WriteToken(jsontext.String(s + "Z"))
+}
+
+func (j *LogJson) marshalProtoDuration(m protoreflect.Message, state *EncoderState) {
+	fields := m.Descriptor().Fields()
+	secs := m.Get(fields.ByNumber(1)).Int()
+	nanos := m.Get(fields.ByNumber(2)).Int()
+	sign := ""
+	if secs < 0 || nanos < 0 {
+		sign = "-"
+	}
+	s := fmt.Sprintf("%s%d.%09d", sign, absInt64(secs), absInt64(nanos))
+	state.Encoder.WriteToken(jsontext.String(trimProtoNanos(s) + "s"))
+}
+
+func absInt64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// trimProtoNanos removes trailing zeros of the 9 fraction digits of s in groups
+// of 3, like protojson does.
+func trimProtoNanos(s string) string {
+	s = strings.TrimSuffix(s, "000")
+	s = strings.TrimSuffix(s, "000")
+	s = strings.TrimSuffix(s, ".000")
+	return s
+}
+
+func (j *LogJson) marshalProtoStruct(m protoreflect.Message, state *EncoderState) {
+	j.marshalProtoMap(m.Descriptor().Fields().ByNumber(1), m.Get(m.Descriptor().Fields().ByNumber(1)).Map(), state)
+}
+
+func (j *LogJson) marshalProtoListValue(m protoreflect.Message, state *EncoderState) {
+	fd := m.Descriptor().Fields().ByNumber(1)
+	j.marshalProtoFieldValue(fd, m.Get(fd), state)
+}
+
+func (j *LogJson) marshalProtoValue(m protoreflect.Message, state *EncoderState) {
+	fd := m.WhichOneof(m.Descriptor().Oneofs().ByName("kind"))
+	if fd == nil {
+		state.Encoder.WriteToken(jsontext.Null)
+		return
+	}
+	j.marshalProtoSingular(fd, m.Get(fd), state)
+}
+
+func (j *LogJson) marshalProtoFieldMask(m protoreflect.Message, state *EncoderState) {
+	list := m.Get(m.Descriptor().Fields().ByNumber(1)).List()
+	paths := make([]string, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		paths = append(paths, protoJsonCamelCase(list.Get(i).String()))
+	}
+	state.Encoder.WriteToken(jsontext.String(strings.Join(paths, ",")))
+}
+
+func protoJsonCamelCase(s string) string {
+	var b strings.Builder
+	upper := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '_' {
+			upper = true
+			continue
+		}
+		if upper && 'a' <= c && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		upper = false
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func (j *LogJson) marshalProtoEmpty(m protoreflect.Message, state *EncoderState) {
+	state.Encoder.WriteToken(jsontext.ObjectStart)
+	state.Encoder.WriteToken(jsontext.ObjectEnd)
+}
+
+func (j *LogJson) marshalProtoWrapper(m protoreflect.Message, state *EncoderState) {
+	fd := m.Descriptor().Fields().ByNumber(1)
+	j.marshalProtoSingular(fd, m.Get(fd), state)
+}
+
+// appendFloat formats f like ES6 and encoding/json do.
+func appendFloat(b []byte, f float64, bits int) []byte {
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	b = strconv.AppendFloat(b, f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
+
+// getProtoFieldLogRuleConf returns the rule of fd from proto options, the
+// log_json_rule option is preferred to the options of getProtoFieldLogJsonValue.
+// A log_json_rule option without action only labels the rule of the others.
+func getProtoFieldLogRuleConf(fd protoreflect.FieldDescriptor) *logRuleConf {
+	var rule *LogJsonRule
+	if proto.HasExtension(fd.Options(), E_LogJsonRule) {
+		rule, _ = proto.GetExtension(fd.Options(), E_LogJsonRule).(*LogJsonRule)
+		if conf := newLogRuleConfFromProto(rule); conf != nil {
+			return conf
+		}
+	}
+	conf := newLogRuleConfFromStr(getProtoFieldLogJsonValue(fd))
+	if conf != nil && rule != nil {
+		conf.classification = rule.GetClassification()
+	}
+	return conf
+}
+
+// getProtoFieldLogJsonValue returns the rule of fd from proto options, the
+// log_json option of fd comes first, then the log_json_default option of the
+// message containing fd. An invalid log_json option falls back to the
+// default, so a typo does not write the field in clear.
+func getProtoFieldLogJsonValue(fd protoreflect.FieldDescriptor) string {
+	if s, ok := proto.GetExtension(fd.Options(), E_LogJson).(string); ok && ValidLogRule(s) {
+		return s
+	}
+	if msgDesc := fd.ContainingMessage(); msgDesc != nil {
+		if s, ok := proto.GetExtension(msgDesc.Options(), E_LogJsonDefault).(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// getProtoMessageLogJsonValue returns the log_json_message option of desc.
+func getProtoMessageLogJsonValue(desc protoreflect.MessageDescriptor) string {
+	if s, ok := proto.GetExtension(desc.Options(), E_LogJsonMessage).(string); ok {
+		return s
+	}
+	return ""
+}
+
+// isProtoEnumHidden reports whether v of fd is an enum value with the
+// log_json_hide option, such values are omitted with their fields, list
+// elements or map entries.
+func (j *LogJson) isProtoEnumHidden(fd protoreflect.FieldDescriptor, v protoreflect.Value, state *EncoderState) bool {
+	if fd.Kind() != protoreflect.EnumKind || j.ignoreFieldRules || state.opts.ignoreLogRules {
+		return false
+	}
+	value := fd.Enum().Values().ByNumber(v.Enum())
+	return value != nil && isProtoEnumValueHidden(value)
+}
+
+func isProtoEnumValueHidden(desc protoreflect.EnumValueDescriptor) bool {
+	hide, _ := proto.GetExtension(desc.Options(), E_LogJsonHide).(bool)
+	return hide
+}
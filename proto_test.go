@@ -0,0 +1,111 @@
+package logjson
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestLogJson_ProtoMessage(t *testing.T) {
+	detail, err := anypb.New(&TestProtoAbc{MyName: proto.String("world")})
+	require.NoError(t, err)
+	extra, err := structpb.NewStruct(map[string]any{"b": 1, "a": []any{"x", true, nil}})
+	require.NoError(t, err)
+	msg := &TestProtoAll{
+		Status:    TestProtoAll_STATUS_PAID.Enum(),
+		Id:        proto.Int64(1 << 60),
+		Ratio:     proto.Float32(0.1),
+		Data:      []byte("hi"),
+		Payload:   &TestProtoAll_Abc{Abc: &TestProtoAbc{MyName: proto.String("hello")}},
+		Items:     []*TestProtoAbc{{MyName: proto.String("hello")}},
+		Counts:    map[string]int32{"b": 2, "a": 1},
+		CreatedAt: timestamppb.New(time.Date(2024, 1, 2, 3, 4, 5, 100000000, time.UTC)),
+		Timeout:   durationpb.New(1500 * time.Millisecond),
+		Detail:    detail,
+		Extra:     extra,
+		Nick:      wrapperspb.String("nick"),
+		Password:  proto.String("secret"),
+	}
+	j := NewLogJson()
+	j.AddLogRule("password", LogRuleOmit())
+	md5Hello := `"5;5d41402abc4b2a76b9719d911017c592"`
+	require.Equal(t, `{"status":"STATUS_PAID","id":"1152921504606846976","ratio":0.1,"data":"aGk=",`+
+		`"abc":{"my_name":`+md5Hello+`},"items":[{"my_name":`+md5Hello+`}],"counts":{"a":1,"b":2},`+
+		`"created_at":"2024-01-02T03:04:05.100Z","timeout":"1.500s",`+
+		`"detail":{"@type":"type.googleapis.com/TestProtoAbc","my_name":"5;7d793037a0760186574b0282f2f435e7"},`+
+		`"extra":{"a":["x",true,null],"b":1},"nick":"nick"}`, string(j.Marshal(msg)))
+}
+
+func TestLogJson_ProtoAnyMessageRule(t *testing.T) {
+	hidden, err := anypb.New(&TestProtoHidden{Token: proto.String("token")})
+	require.NoError(t, err)
+	secret, err := anypb.New(&TestProtoSecret{Token: proto.String("token")})
+	require.NoError(t, err)
+	buf, err := proto.MarshalOptions{Deterministic: true}.Marshal(&TestProtoSecret{Token: proto.String("token")})
+	require.NoError(t, err)
+	j := NewLogJson()
+	require.Equal(t, `{"detail":{"@type":"type.googleapis.com/TestProtoHidden","value":null}}`,
+		string(j.Marshal(&TestProtoAll{Detail: hidden})))
+	require.Equal(t, `{"detail":{"@type":"type.googleapis.com/TestProtoSecret","value":"`+md5LogString(string(buf))+`"}}`,
+		string(j.Marshal(&TestProtoAll{Detail: secret})))
+}
+
+func TestLogJson_ProtoInvalidLogJson(t *testing.T) {
+	msgOpts := &descriptorpb.MessageOptions{}
+	proto.SetExtension(msgOpts, E_LogJsonDefault, "sha256")
+	fieldOpts := &descriptorpb.FieldOptions{}
+	proto.SetExtension(fieldOpts, E_LogJson, "md55")
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:   proto.String("typo.proto"),
+		Syntax: proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name:    proto.String("Typo"),
+			Options: msgOpts,
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("name"),
+				JsonName: proto.String("name"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Options:  fieldOpts,
+			}},
+		}},
+	}, nil)
+	require.NoError(t, err)
+	desc := fd.Messages().Get(0)
+	msg := dynamicpb.NewMessage(desc)
+	msg.Set(desc.Fields().Get(0), protoreflect.ValueOfString("hello"))
+	require.Equal(t, `{"name":"`+sha256LogString("hello")+`"}`, string(NewLogJson().Marshal(msg)))
+}
+
+func TestLogJson_ProtoJsonName(t *testing.T) {
+	j := NewLogJson()
+	j.SetProtoJsonName(true)
+	msg := &TestProtoAll{Status: TestProtoAll_STATUS_UNKNOWN.Enum(), Payload: &TestProtoAll_Text{Text: "hello"},
+		CreatedAt: timestamppb.New(time.Unix(0, 0))}
+	require.Equal(t, `{"status":"STATUS_UNKNOWN","text":"hello","createdAt":"1970-01-01T00:00:00Z"}`,
+		string(j.Marshal(msg)))
+}
+
+func TestLogJson_ProtoCycle(t *testing.T) {
+	s := &structpb.Struct{Fields: map[string]*structpb.Value{"a": structpb.NewNumberValue(1)}}
+	s.Fields["self"] = structpb.NewStructValue(s)
+	type Abc struct {
+		S *structpb.Struct
+	}
+	j := NewLogJson()
+	j.SetCycleDetectionDepth(0)
+	require.Equal(t, `{"S":{"a":1,"self":{"$ref":"$.S"}}}`, string(j.Marshal(Abc{S: s})))
+	require.Contains(t, string(NewLogJson().Marshal(Abc{S: s})), `{"$ref":"$.S.self.self`)
+}
@@ -0,0 +1,124 @@
+package logjson
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+// RedactedText is written instead of the values of Secret.
+const RedactedText = "[REDACTED]"
+
+// Secret holds a value which is written as "[REDACTED]" by logjson, fmt,
+// encoding/json, json v2 and slog, the value is only returned by Reveal.
+type Secret[T any] struct {
+	value T
+}
+
+func NewSecret[T any](value T) Secret[T] {
+	return Secret[T]{value: value}
+}
+
+// Reveal returns the value of the secret.
+func (s Secret[T]) Reveal() T {
+	return s.value
+}
+
+func (s Secret[T]) String() string {
+	return RedactedText
+}
+
+// Format writes "[REDACTED]" for all verbs, including %#v.
+func (s Secret[T]) Format(f fmt.State, verb rune) {
+	io.WriteString(f, RedactedText)
+}
+
+func (s Secret[T]) LogValue() slog.Value {
+	return slog.StringValue(RedactedText)
+}
+
+func (s Secret[T]) MarshalLogJSON(enc *jsontext.Encoder) {
+	enc.WriteToken(jsontext.String(RedactedText))
+}
+
+func (s Secret[T]) MarshalJSONV2(enc *jsontext.Encoder, opts json.Options) error {
+	return enc.WriteToken(jsontext.String(RedactedText))
+}
+
+func (s Secret[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(RedactedText)
+}
+
+// UnmarshalJSON sets the value of the secret, so secrets can be loaded from
+// configuration files.
+func (s *Secret[T]) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, &s.value)
+}
+
+// Redacted holds a value which is written as its hash or another string rule
+// applied to its fmt form, so logs can be correlated without revealing the
+// value. It is written like Secret by the same encoders, the value is only
+// returned by Reveal.
+type Redacted[T any] struct {
+	value T
+	conf  *logRuleConf
+}
+
+var defaultRedactedConf = newLogRuleConf(LogRuleSha256())
+
+// NewRedacted returns a Redacted writing value with rule, such as
+// LogRuleMd5() or LogRuleMask(0, 4). A nil rule is LogRuleSha256(), rules
+// which do not change strings, such as LogRuleOmit(), write "[REDACTED]".
+func NewRedacted[T any](value T, rule LogRule) Redacted[T] {
+	r := Redacted[T]{value: value}
+	if rule != nil {
+		r.conf = newLogRuleConf(rule)
+	}
+	return r
+}
+
+// Reveal returns the value.
+func (r Redacted[T]) Reveal() T {
+	return r.value
+}
+
+func (r Redacted[T]) String() string {
+	conf := r.conf
+	if conf == nil {
+		conf = defaultRedactedConf
+	}
+	if s, ok := conf.applyString(fmt.Sprint(r.value)); ok {
+		return s
+	}
+	return RedactedText
+}
+
+// Format writes String for all verbs, including %#v.
+func (r Redacted[T]) Format(f fmt.State, verb rune) {
+	io.WriteString(f, r.String())
+}
+
+func (r Redacted[T]) LogValue() slog.Value {
+	return slog.StringValue(r.String())
+}
+
+func (r Redacted[T]) MarshalLogJSON(enc *jsontext.Encoder) {
+	enc.WriteToken(jsontext.String(r.String()))
+}
+
+func (r Redacted[T]) MarshalJSONV2(enc *jsontext.Encoder, opts json.Options) error {
+	return enc.WriteToken(jsontext.String(r.String()))
+}
+
+func (r Redacted[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON sets the value, the rule is reset to LogRuleSha256().
+func (r *Redacted[T]) UnmarshalJSON(b []byte) error {
+	r.conf = nil
+	return json.Unmarshal(b, &r.value)
+}
@@ -0,0 +1,63 @@
+package logjson
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/go-json-experiment/json"
+	"github.com/stretchr/testify/require"
+)
+
+type testSecretConfig struct {
+	User       string
+	DBPassword Secret[string]
+	Token      Redacted[string]
+	Pin        Secret[int]
+}
+
+func TestSecret(t *testing.T) {
+	conf := testSecretConfig{
+		User:       "user",
+		DBPassword: NewSecret("password"),
+		Token:      NewRedacted("hello", LogRuleMd5()),
+		Pin:        NewSecret(1234),
+	}
+	expected := `{"User":"user","DBPassword":"[REDACTED]","Token":"5;5d41402abc4b2a76b9719d911017c592","Pin":"[REDACTED]"}`
+	require.Equal(t, expected, marshalToLogStr(conf))
+	require.Equal(t, expected, marshalToLogStr(&conf))
+	buf, err := stdjson.Marshal(conf)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(buf))
+	buf, err = json.Marshal(conf)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(buf))
+	require.Equal(t, "{user [REDACTED] 5;5d41402abc4b2a76b9719d911017c592 [REDACTED]}", fmt.Sprintf("%v", conf))
+	require.Equal(t, "[REDACTED]", fmt.Sprintf("%#v", conf.DBPassword))
+	require.Equal(t, "[REDACTED]", fmt.Sprintf("%d", conf.Pin))
+
+	var out bytes.Buffer
+	slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{})).Info("msg", "password", conf.DBPassword, "token", conf.Token)
+	require.Contains(t, out.String(), `password=[REDACTED] token=5;5d41402abc4b2a76b9719d911017c592`)
+
+	require.Equal(t, "password", conf.DBPassword.Reveal())
+	require.Equal(t, "hello", conf.Token.Reveal())
+	require.Equal(t, 1234, conf.Pin.Reveal())
+}
+
+func TestSecret_Unmarshal(t *testing.T) {
+	var conf testSecretConfig
+	require.NoError(t, stdjson.Unmarshal([]byte(`{"User":"user","DBPassword":"password","Token":"hello","Pin":1234}`), &conf))
+	require.Equal(t, "password", conf.DBPassword.Reveal())
+	require.Equal(t, 1234, conf.Pin.Reveal())
+	require.Equal(t, sha256LogString("hello"), conf.Token.String())
+}
+
+func TestRedacted(t *testing.T) {
+	require.Equal(t, `"*******5678"`, marshalToLogStr(NewRedacted("13812345678", LogRuleMask(0, 4))))
+	require.Equal(t, `"[REDACTED]"`, marshalToLogStr(NewRedacted("13812345678", LogRuleOmit())))
+	require.Equal(t, `"`+sha256LogString("42")+`"`, marshalToLogStr(NewRedacted(42, nil)))
+	require.Equal(t, `"`+sha256LogString("")+`"`, marshalToLogStr(Redacted[string]{}))
+}
@@ -0,0 +1,285 @@
+package logjson
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-json-experiment/json"
+	"github.com/go-json-experiment/json/jsontext"
+)
+
+// ShredPrefix starts every ciphertext written by LogRuleShred.
+const ShredPrefix = "shred1:"
+
+// ErrKeyNotFound is returned by KeyStore when the key of a subject does not
+// exist, for example because the subject was deleted.
+var ErrKeyNotFound = errors.New("logjson: key not found")
+
+// KeyStore stores the data keys of subjects for LogRuleShred. Deleting the keys
+// of a subject makes all values logged for it unrecoverable.
+type KeyStore interface {
+	// DataKey returns the current 32 bytes key of subject, it creates the key
+	// when subject has none.
+	DataKey(subject string) (keyId string, key []byte, err error)
+	// LookupKey returns the key keyId of subject.
+	LookupKey(subject string, keyId string) ([]byte, error)
+	// DeleteSubject deletes all keys of subject.
+	DeleteSubject(subject string) error
+}
+
+// ShredSubject returns the subject of the value being marshaled.
+type ShredSubject func(state *EncoderState) (string, bool)
+
+// SubjectFromField uses the field named name of the struct which contains the
+// value as the subject. name is the name in the output, like the key of
+// LogJson.AddLogRule.
+func SubjectFromField(name string) ShredSubject {
+	return func(state *EncoderState) (string, bool) {
+		v, ok := state.structFieldValue(name)
+		if !ok {
+			return "", false
+		}
+		for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return "", false
+			}
+			v = v.Elem()
+		}
+		s := fmt.Sprint(v.Interface())
+		return s, s != ""
+	}
+}
+
+// SubjectFromContext uses the result of f on the context of the call as the
+// subject, see WithContext.
+func SubjectFromContext(f func(ctx context.Context) string) ShredSubject {
+	return func(state *EncoderState) (string, bool) {
+		s := f(state.Context())
+		return s, s != ""
+	}
+}
+
+// LogRuleShred encrypts string values with the data key of their subject.
+// Values without subject or key and values which are not strings are written
+// as null. The result has the form
+// shred1:<base64 subject>:<key id>:<base64 nonce and ciphertext>, see Unshred.
+func LogRuleShred(store KeyStore, subject ShredSubject) LogRule {
+	return func(conf *logRuleConf) {
+		conf.name = "shred"
+		conf.shred = &shredRule{store: store, subject: subject}
+	}
+}
+
+type shredRule struct {
+	store   KeyStore
+	subject ShredSubject
+}
+
+func (r *shredRule) token(s string, state *EncoderState) jsontext.Token {
+	subject, ok := r.subject(state)
+	if !ok {
+		return jsontext.Null
+	}
+	keyId, key, err := r.store.DataKey(subject)
+	if err != nil {
+		return jsontext.Null
+	}
+	aead, err := newShredAead(key)
+	if err != nil {
+		return jsontext.Null
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(s)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return jsontext.Null
+	}
+	out := aead.Seal(nonce, nonce, []byte(s), shredAdditionalData(subject, keyId))
+	return jsontext.String(ShredPrefix + base64.RawURLEncoding.EncodeToString([]byte(subject)) +
+		":" + keyId + ":" + base64.RawURLEncoding.EncodeToString(out))
+}
+
+// Unshred decrypts a value written by LogRuleShred.
+func Unshred(store KeyStore, s string) (string, error) {
+	if !strings.HasPrefix(s, ShredPrefix) {
+		return "", errors.New("logjson: invalid shred prefix")
+	}
+	parts := strings.Split(s[len(ShredPrefix):], ":")
+	if len(parts) != 3 {
+		return "", errors.New("logjson: invalid shred format")
+	}
+	subjectBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", err
+	}
+	subject, keyId := string(subjectBytes), parts[1]
+	key, err := store.LookupKey(subject, keyId)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newShredAead(key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < aead.NonceSize() {
+		return "", errors.New("logjson: shred ciphertext too short")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():],
+		shredAdditionalData(subject, keyId))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func newShredAead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func shredAdditionalData(subject, keyId string) []byte {
+	return []byte(ShredPrefix + subject + ":" + keyId)
+}
+
+// FileKeyStore is a KeyStore keeping one file per subject in a directory.
+// Several stores may share a directory, keys are cached only while their
+// file is unchanged, so a subject deleted by one store is deleted for all.
+type FileKeyStore struct {
+	dir   string
+	mux   sync.Mutex
+	cache map[string]*fileSubjectKeys
+}
+
+type fileSubjectKeys struct {
+	Current string            `json:"current"`
+	Keys    map[string][]byte `json:"keys"`
+	// info is the file the keys were read from.
+	info os.FileInfo
+}
+
+func NewFileKeyStore(dir string) (*FileKeyStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileKeyStore{
+		dir:   dir,
+		cache: make(map[string]*fileSubjectKeys),
+	}, nil
+}
+
+func (s *FileKeyStore) DataKey(subject string) (string, []byte, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	keys, err := s.load(subject)
+	if err == nil {
+		return keys.Current, keys.Keys[keys.Current], nil
+	}
+	if !errors.Is(err, ErrKeyNotFound) {
+		return "", nil, err
+	}
+	key := make([]byte, 32)
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(key); err != nil {
+		return "", nil, err
+	}
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", nil, err
+	}
+	keys = &fileSubjectKeys{
+		Current: hex.EncodeToString(idBytes),
+		Keys:    make(map[string][]byte),
+	}
+	keys.Keys[keys.Current] = key
+	content, err := json.Marshal(keys)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := os.WriteFile(s.path(subject), content, 0o600); err != nil {
+		return "", nil, err
+	}
+	if keys.info, err = os.Stat(s.path(subject)); err != nil {
+		return "", nil, err
+	}
+	s.cache[subject] = keys
+	return keys.Current, key, nil
+}
+
+func (s *FileKeyStore) LookupKey(subject string, keyId string) ([]byte, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	keys, err := s.load(subject)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := keys.Keys[keyId]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+func (s *FileKeyStore) DeleteSubject(subject string) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	delete(s.cache, subject)
+	err := os.Remove(s.path(subject))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// load returns the keys of subject, the cached keys are used while the file
+// of subject is unchanged.
+func (s *FileKeyStore) load(subject string) (*fileSubjectKeys, error) {
+	f, err := os.Open(s.path(subject))
+	if errors.Is(err, os.ErrNotExist) {
+		delete(s.cache, subject)
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if keys, ok := s.cache[subject]; ok && os.SameFile(keys.info, info) &&
+		keys.info.ModTime().Equal(info.ModTime()) && keys.info.Size() == info.Size() {
+		return keys, nil
+	}
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	keys := &fileSubjectKeys{info: info}
+	if err := json.Unmarshal(content, keys); err != nil {
+		return nil, err
+	}
+	s.cache[subject] = keys
+	return keys, nil
+}
+
+func (s *FileKeyStore) path(subject string) string {
+	sum := sha256.Sum256([]byte(subject))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
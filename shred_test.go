@@ -0,0 +1,91 @@
+package logjson
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-json-experiment/json"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogJson_Shred(t *testing.T) {
+	store, err := NewFileKeyStore(t.TempDir())
+	require.NoError(t, err)
+	type Abc struct {
+		UserId int64
+		Email  string
+	}
+	j := NewLogJson()
+	j.AddLogRule("Email", LogRuleShred(store, SubjectFromField("UserId")))
+	var out Abc
+	require.NoError(t, json.Unmarshal(j.Marshal(Abc{UserId: 3, Email: "a@b.com"}), &out))
+	require.True(t, strings.HasPrefix(out.Email, ShredPrefix))
+	s, err := Unshred(store, out.Email)
+	require.NoError(t, err)
+	require.Equal(t, "a@b.com", s)
+
+	require.NoError(t, store.DeleteSubject("3"))
+	_, err = Unshred(store, out.Email)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestFileKeyStore_SharedDir(t *testing.T) {
+	dir := t.TempDir()
+	store1, err := NewFileKeyStore(dir)
+	require.NoError(t, err)
+	store2, err := NewFileKeyStore(dir)
+	require.NoError(t, err)
+	keyId, key, err := store1.DataKey("3")
+	require.NoError(t, err)
+	key2, err := store2.LookupKey("3", keyId)
+	require.NoError(t, err)
+	require.Equal(t, key, key2)
+
+	require.NoError(t, store2.DeleteSubject("3"))
+	_, err = store1.LookupKey("3", keyId)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	newKeyId, _, err := store1.DataKey("3")
+	require.NoError(t, err)
+	require.NotEqual(t, keyId, newKeyId)
+	_, err = store2.LookupKey("3", newKeyId)
+	require.NoError(t, err)
+}
+
+func TestLogJson_ShredNonString(t *testing.T) {
+	store, err := NewFileKeyStore(t.TempDir())
+	require.NoError(t, err)
+	type Address struct {
+		City string
+	}
+	type Abc struct {
+		UserId  int64
+		Balance int64
+		Address Address
+	}
+	j := NewLogJson()
+	j.AddLogRule("Balance", LogRuleShred(store, SubjectFromField("UserId")))
+	j.AddLogRule("Address", LogRuleShred(store, SubjectFromField("UserId")))
+	require.Equal(t, `{"UserId":3,"Balance":null,"Address":null}`,
+		string(j.Marshal(Abc{UserId: 3, Balance: 100, Address: Address{City: "SZ"}})))
+}
+
+func TestLogJson_ShredSubjectFromContext(t *testing.T) {
+	store, err := NewFileKeyStore(t.TempDir())
+	require.NoError(t, err)
+	j := NewLogJson()
+	j.AddLogRule("Email", LogRuleShred(store, SubjectFromContext(func(ctx context.Context) string {
+		s, _ := ctx.Value(testUserKey{}).(string)
+		return s
+	})))
+	type Abc struct {
+		Email string
+	}
+	require.Equal(t, `{"Email":null}`, string(j.Marshal(Abc{Email: "a@b.com"})))
+	ctx := context.WithValue(context.Background(), testUserKey{}, "u1")
+	var out Abc
+	require.NoError(t, json.Unmarshal(j.MarshalContext(ctx, Abc{Email: "a@b.com"}), &out))
+	s, err := Unshred(store, out.Email)
+	require.NoError(t, err)
+	require.Equal(t, "a@b.com", s)
+}
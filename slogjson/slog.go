@@ -30,13 +30,19 @@ func NewHandler(conf *HandlerOption) *Handler {
 type HandlerOption struct {
 	Writer  io.Writer
 	LogJson *logjson.LogJson
-	Level   slog.Leveler
+	// Policy makes the handler use a view of LogJson, see logjson.LogJson.View.
+	Policy *logjson.LogPolicy
+	Level  slog.Leveler
 }
 
 func (o *HandlerOption) init() {
 	if o.LogJson == nil {
 		o.LogJson = logjson.DefaultLogJson()
 	}
+	if o.Policy != nil {
+		o.LogJson = o.LogJson.View(o.Policy)
+		o.Policy = nil
+	}
 }
 
 func (h *Handler) Enabled(c context.Context, l slog.Level) bool {
@@ -51,6 +57,7 @@ func (h *Handler) Handle(c context.Context, record slog.Record) error {
 	buf := bytes.NewBuffer(nil)
 	h.writeBasicInfo(buf, record)
 	state := logjson.NewEncoderState(buf)
+	state.SetOptions(logjson.WithContext(c))
 	state.WriteToken(jsontext.ObjectStart)
 	h.appendNonBuiltIns(state, record)
 	state.WriteToken(jsontext.ObjectEnd)
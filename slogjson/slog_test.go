@@ -2,6 +2,7 @@ package slogjson
 
 import (
 	"bytes"
+	"github.com/ethanvc/logjson"
 	"github.com/stretchr/testify/require"
 	"log/slog"
 	"testing"
@@ -24,3 +25,25 @@ func Test_Basic(t *testing.T) {
 		slog.Any("abc", abc))
 	require.Regexp(t, `.*|Test|{"xx":"abc","abc":{"Name":"test"}}`, buf.String())
 }
+
+func Test_Policy(t *testing.T) {
+	type Abc struct {
+		Name string
+	}
+	j := logjson.NewLogJson()
+	debugBuf := bytes.NewBuffer(nil)
+	debugLogger := slog.New(NewHandler(&HandlerOption{
+		Writer:  debugBuf,
+		LogJson: j,
+	}))
+	storeBuf := bytes.NewBuffer(nil)
+	storeLogger := slog.New(NewHandler(&HandlerOption{
+		Writer:  storeBuf,
+		LogJson: j,
+		Policy:  logjson.NewLogPolicy().AddLogRule("Name", logjson.LogRuleOmit()),
+	}))
+	debugLogger.Info("Test", slog.Any("abc", Abc{Name: "test"}))
+	storeLogger.Info("Test", slog.Any("abc", Abc{Name: "test"}))
+	require.Contains(t, debugBuf.String(), `{"abc":{"Name":"test"}}`)
+	require.Contains(t, storeBuf.String(), `{"abc":{}}`)
+}
@@ -10,6 +10,11 @@ import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	_ "google.golang.org/protobuf/types/descriptorpb"
+	anypb "google.golang.org/protobuf/types/known/anypb"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
 	reflect "reflect"
 	sync "sync"
 )
@@ -21,6 +26,118 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type TestProtoAll_Status int32
+
+const (
+	TestProtoAll_STATUS_UNKNOWN TestProtoAll_Status = 0
+	TestProtoAll_STATUS_PAID    TestProtoAll_Status = 1
+)
+
+// Enum value maps for TestProtoAll_Status.
+var (
+	TestProtoAll_Status_name = map[int32]string{
+		0: "STATUS_UNKNOWN",
+		1: "STATUS_PAID",
+	}
+	TestProtoAll_Status_value = map[string]int32{
+		"STATUS_UNKNOWN": 0,
+		"STATUS_PAID":    1,
+	}
+)
+
+func (x TestProtoAll_Status) Enum() *TestProtoAll_Status {
+	p := new(TestProtoAll_Status)
+	*p = x
+	return p
+}
+
+func (x TestProtoAll_Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TestProtoAll_Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_test_proto_enumTypes[0].Descriptor()
+}
+
+func (TestProtoAll_Status) Type() protoreflect.EnumType {
+	return &file_test_proto_enumTypes[0]
+}
+
+func (x TestProtoAll_Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Do not use.
+func (x *TestProtoAll_Status) UnmarshalJSON(b []byte) error {
+	num, err := protoimpl.X.UnmarshalJSONEnum(x.Descriptor(), b)
+	if err != nil {
+		return err
+	}
+	*x = TestProtoAll_Status(num)
+	return nil
+}
+
+// Deprecated: Use TestProtoAll_Status.Descriptor instead.
+func (TestProtoAll_Status) EnumDescriptor() ([]byte, []int) {
+	return file_test_proto_rawDescGZIP(), []int{1, 0}
+}
+
+type TestProtoDefault_Level int32
+
+const (
+	TestProtoDefault_LEVEL_LOW   TestProtoDefault_Level = 0
+	TestProtoDefault_LEVEL_FRAUD TestProtoDefault_Level = 1
+)
+
+// Enum value maps for TestProtoDefault_Level.
+var (
+	TestProtoDefault_Level_name = map[int32]string{
+		0: "LEVEL_LOW",
+		1: "LEVEL_FRAUD",
+	}
+	TestProtoDefault_Level_value = map[string]int32{
+		"LEVEL_LOW":   0,
+		"LEVEL_FRAUD": 1,
+	}
+)
+
+func (x TestProtoDefault_Level) Enum() *TestProtoDefault_Level {
+	p := new(TestProtoDefault_Level)
+	*p = x
+	return p
+}
+
+func (x TestProtoDefault_Level) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TestProtoDefault_Level) Descriptor() protoreflect.EnumDescriptor {
+	return file_test_proto_enumTypes[1].Descriptor()
+}
+
+func (TestProtoDefault_Level) Type() protoreflect.EnumType {
+	return &file_test_proto_enumTypes[1]
+}
+
+func (x TestProtoDefault_Level) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Do not use.
+func (x *TestProtoDefault_Level) UnmarshalJSON(b []byte) error {
+	num, err := protoimpl.X.UnmarshalJSONEnum(x.Descriptor(), b)
+	if err != nil {
+		return err
+	}
+	*x = TestProtoDefault_Level(num)
+	return nil
+}
+
+// Deprecated: Use TestProtoDefault_Level.Descriptor instead.
+func (TestProtoDefault_Level) EnumDescriptor() ([]byte, []int) {
+	return file_test_proto_rawDescGZIP(), []int{4, 0}
+}
+
 type TestProtoAbc struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -68,19 +185,512 @@ func (x *TestProtoAbc) GetMyName() string {
 	return ""
 }
 
+type TestProtoAll struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status *TestProtoAll_Status `protobuf:"varint,1,opt,name=status,enum=TestProtoAll_Status" json:"status,omitempty"`
+	Id     *int64               `protobuf:"varint,2,opt,name=id" json:"id,omitempty"`
+	Ratio  *float32             `protobuf:"fixed32,3,opt,name=ratio" json:"ratio,omitempty"`
+	Data   []byte               `protobuf:"bytes,4,opt,name=data" json:"data,omitempty"`
+	// Types that are assignable to Payload:
+	//	*TestProtoAll_Text
+	//	*TestProtoAll_Abc
+	Payload   isTestProtoAll_Payload  `protobuf_oneof:"payload"`
+	Items     []*TestProtoAbc         `protobuf:"bytes,7,rep,name=items" json:"items,omitempty"`
+	Counts    map[string]int32        `protobuf:"bytes,8,rep,name=counts" json:"counts,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	CreatedAt *timestamppb.Timestamp  `protobuf:"bytes,9,opt,name=created_at,json=createdAt" json:"created_at,omitempty"`
+	Timeout   *durationpb.Duration    `protobuf:"bytes,10,opt,name=timeout" json:"timeout,omitempty"`
+	Detail    *anypb.Any              `protobuf:"bytes,11,opt,name=detail" json:"detail,omitempty"`
+	Extra     *structpb.Struct        `protobuf:"bytes,12,opt,name=extra" json:"extra,omitempty"`
+	Nick      *wrapperspb.StringValue `protobuf:"bytes,13,opt,name=nick" json:"nick,omitempty"`
+	Password  *string                 `protobuf:"bytes,14,opt,name=password" json:"password,omitempty"`
+}
+
+func (x *TestProtoAll) Reset() {
+	*x = TestProtoAll{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_test_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TestProtoAll) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestProtoAll) ProtoMessage() {}
+
+func (x *TestProtoAll) ProtoReflect() protoreflect.Message {
+	mi := &file_test_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestProtoAll.ProtoReflect.Descriptor instead.
+func (*TestProtoAll) Descriptor() ([]byte, []int) {
+	return file_test_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TestProtoAll) GetStatus() TestProtoAll_Status {
+	if x != nil && x.Status != nil {
+		return *x.Status
+	}
+	return TestProtoAll_STATUS_UNKNOWN
+}
+
+func (x *TestProtoAll) GetId() int64 {
+	if x != nil && x.Id != nil {
+		return *x.Id
+	}
+	return 0
+}
+
+func (x *TestProtoAll) GetRatio() float32 {
+	if x != nil && x.Ratio != nil {
+		return *x.Ratio
+	}
+	return 0
+}
+
+func (x *TestProtoAll) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (m *TestProtoAll) GetPayload() isTestProtoAll_Payload {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
+func (x *TestProtoAll) GetText() string {
+	if x, ok := x.GetPayload().(*TestProtoAll_Text); ok {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *TestProtoAll) GetAbc() *TestProtoAbc {
+	if x, ok := x.GetPayload().(*TestProtoAll_Abc); ok {
+		return x.Abc
+	}
+	return nil
+}
+
+func (x *TestProtoAll) GetItems() []*TestProtoAbc {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *TestProtoAll) GetCounts() map[string]int32 {
+	if x != nil {
+		return x.Counts
+	}
+	return nil
+}
+
+func (x *TestProtoAll) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *TestProtoAll) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+func (x *TestProtoAll) GetDetail() *anypb.Any {
+	if x != nil {
+		return x.Detail
+	}
+	return nil
+}
+
+func (x *TestProtoAll) GetExtra() *structpb.Struct {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+func (x *TestProtoAll) GetNick() *wrapperspb.StringValue {
+	if x != nil {
+		return x.Nick
+	}
+	return nil
+}
+
+func (x *TestProtoAll) GetPassword() string {
+	if x != nil && x.Password != nil {
+		return *x.Password
+	}
+	return ""
+}
+
+type isTestProtoAll_Payload interface {
+	isTestProtoAll_Payload()
+}
+
+type TestProtoAll_Text struct {
+	Text string `protobuf:"bytes,5,opt,name=text,oneof"`
+}
+
+type TestProtoAll_Abc struct {
+	Abc *TestProtoAbc `protobuf:"bytes,6,opt,name=abc,oneof"`
+}
+
+func (*TestProtoAll_Text) isTestProtoAll_Payload() {}
+
+func (*TestProtoAll_Abc) isTestProtoAll_Payload() {}
+
+type TestProtoSecret struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Token *string `protobuf:"bytes,1,opt,name=token" json:"token,omitempty"`
+}
+
+func (x *TestProtoSecret) Reset() {
+	*x = TestProtoSecret{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_test_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TestProtoSecret) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestProtoSecret) ProtoMessage() {}
+
+func (x *TestProtoSecret) ProtoReflect() protoreflect.Message {
+	mi := &file_test_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestProtoSecret.ProtoReflect.Descriptor instead.
+func (*TestProtoSecret) Descriptor() ([]byte, []int) {
+	return file_test_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TestProtoSecret) GetToken() string {
+	if x != nil && x.Token != nil {
+		return *x.Token
+	}
+	return ""
+}
+
+type TestProtoHidden struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Token *string `protobuf:"bytes,1,opt,name=token" json:"token,omitempty"`
+}
+
+func (x *TestProtoHidden) Reset() {
+	*x = TestProtoHidden{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_test_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TestProtoHidden) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestProtoHidden) ProtoMessage() {}
+
+func (x *TestProtoHidden) ProtoReflect() protoreflect.Message {
+	mi := &file_test_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestProtoHidden.ProtoReflect.Descriptor instead.
+func (*TestProtoHidden) Descriptor() ([]byte, []int) {
+	return file_test_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TestProtoHidden) GetToken() string {
+	if x != nil && x.Token != nil {
+		return *x.Token
+	}
+	return ""
+}
+
+type TestProtoDefault struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name   *string                 `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	City   *string                 `protobuf:"bytes,2,opt,name=city" json:"city,omitempty"`
+	Level  *TestProtoDefault_Level `protobuf:"varint,3,opt,name=level,enum=TestProtoDefault_Level" json:"level,omitempty"`
+	Secret *TestProtoSecret        `protobuf:"bytes,4,opt,name=secret" json:"secret,omitempty"`
+	Hidden *TestProtoHidden        `protobuf:"bytes,5,opt,name=hidden" json:"hidden,omitempty"`
+}
+
+func (x *TestProtoDefault) Reset() {
+	*x = TestProtoDefault{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_test_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TestProtoDefault) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestProtoDefault) ProtoMessage() {}
+
+func (x *TestProtoDefault) ProtoReflect() protoreflect.Message {
+	mi := &file_test_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestProtoDefault.ProtoReflect.Descriptor instead.
+func (*TestProtoDefault) Descriptor() ([]byte, []int) {
+	return file_test_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *TestProtoDefault) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *TestProtoDefault) GetCity() string {
+	if x != nil && x.City != nil {
+		return *x.City
+	}
+	return ""
+}
+
+func (x *TestProtoDefault) GetLevel() TestProtoDefault_Level {
+	if x != nil && x.Level != nil {
+		return *x.Level
+	}
+	return TestProtoDefault_LEVEL_LOW
+}
+
+func (x *TestProtoDefault) GetSecret() *TestProtoSecret {
+	if x != nil {
+		return x.Secret
+	}
+	return nil
+}
+
+func (x *TestProtoDefault) GetHidden() *TestProtoHidden {
+	if x != nil {
+		return x.Hidden
+	}
+	return nil
+}
+
+type TestProtoRule struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Phone *string `protobuf:"bytes,1,opt,name=phone" json:"phone,omitempty"`
+	Email *string `protobuf:"bytes,2,opt,name=email" json:"email,omitempty"`
+	Note  *string `protobuf:"bytes,3,opt,name=note" json:"note,omitempty"`
+}
+
+func (x *TestProtoRule) Reset() {
+	*x = TestProtoRule{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_test_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TestProtoRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestProtoRule) ProtoMessage() {}
+
+func (x *TestProtoRule) ProtoReflect() protoreflect.Message {
+	mi := &file_test_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestProtoRule.ProtoReflect.Descriptor instead.
+func (*TestProtoRule) Descriptor() ([]byte, []int) {
+	return file_test_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TestProtoRule) GetPhone() string {
+	if x != nil && x.Phone != nil {
+		return *x.Phone
+	}
+	return ""
+}
+
+func (x *TestProtoRule) GetEmail() string {
+	if x != nil && x.Email != nil {
+		return *x.Email
+	}
+	return ""
+}
+
+func (x *TestProtoRule) GetNote() string {
+	if x != nil && x.Note != nil {
+		return *x.Note
+	}
+	return ""
+}
+
 var File_test_proto protoreflect.FileDescriptor
 
 var file_test_proto_rawDesc = []byte{
 	0x0a, 0x0a, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x20, 0x67, 0x6f,
 	0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x65,
-	0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x0d,
-	0x6c, 0x6f, 0x67, 0x6a, 0x73, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x30, 0x0a,
-	0x0c, 0x54, 0x65, 0x73, 0x74, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x41, 0x62, 0x63, 0x12, 0x20, 0x0a,
-	0x07, 0x6d, 0x79, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x07,
-	0x8a, 0x9a, 0x30, 0x03, 0x6d, 0x64, 0x35, 0x52, 0x06, 0x6d, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x42,
-	0x24, 0x5a, 0x22, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x65, 0x74,
-	0x68, 0x61, 0x6e, 0x76, 0x63, 0x2f, 0x6c, 0x6f, 0x67, 0x6a, 0x73, 0x6f, 0x6e, 0x3b, 0x6c, 0x6f,
-	0x67, 0x6a, 0x73, 0x6f, 0x6e,
+	0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x19,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f,
+	0x61, 0x6e, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x75, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1c, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x73, 0x74, 0x72, 0x75, 0x63,
+	0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x77, 0x72, 0x61, 0x70, 0x70, 0x65,
+	0x72, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x0d, 0x6c, 0x6f, 0x67, 0x6a, 0x73, 0x6f,
+	0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x30, 0x0a, 0x0c, 0x54, 0x65, 0x73, 0x74, 0x50,
+	0x72, 0x6f, 0x74, 0x6f, 0x41, 0x62, 0x63, 0x12, 0x20, 0x0a, 0x07, 0x6d, 0x79, 0x5f, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x07, 0x8a, 0x9a, 0x30, 0x03, 0x6d, 0x64,
+	0x35, 0x52, 0x06, 0x6d, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x97, 0x05, 0x0a, 0x0c, 0x54, 0x65,
+	0x73, 0x74, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x41, 0x6c, 0x6c, 0x12, 0x2c, 0x0a, 0x06, 0x73, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x14, 0x2e, 0x54, 0x65, 0x73,
+	0x74, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x41, 0x6c, 0x6c, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x02, 0x52, 0x05, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x12, 0x12,
+	0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61,
+	0x74, 0x61, 0x12, 0x14, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09,
+	0x48, 0x00, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x21, 0x0a, 0x03, 0x61, 0x62, 0x63, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x54, 0x65, 0x73, 0x74, 0x50, 0x72, 0x6f, 0x74,
+	0x6f, 0x41, 0x62, 0x63, 0x48, 0x00, 0x52, 0x03, 0x61, 0x62, 0x63, 0x12, 0x23, 0x0a, 0x05, 0x69,
+	0x74, 0x65, 0x6d, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x54, 0x65, 0x73,
+	0x74, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x41, 0x62, 0x63, 0x52, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73,
+	0x12, 0x31, 0x0a, 0x06, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x19, 0x2e, 0x54, 0x65, 0x73, 0x74, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x41, 0x6c, 0x6c, 0x2e,
+	0x43, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x63, 0x6f, 0x75,
+	0x6e, 0x74, 0x73, 0x12, 0x39, 0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61,
+	0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x33,
+	0x0a, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x07, 0x74, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x12, 0x2c, 0x0a, 0x06, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x18, 0x0b, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x41, 0x6e, 0x79, 0x52, 0x06, 0x64, 0x65, 0x74, 0x61, 0x69,
+	0x6c, 0x12, 0x2d, 0x0a, 0x05, 0x65, 0x78, 0x74, 0x72, 0x61, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x17, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x53, 0x74, 0x72, 0x75, 0x63, 0x74, 0x52, 0x05, 0x65, 0x78, 0x74, 0x72, 0x61,
+	0x12, 0x30, 0x0a, 0x04, 0x6e, 0x69, 0x63, 0x6b, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x04, 0x6e, 0x69,
+	0x63, 0x6b, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x0e,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x1a, 0x39,
+	0x0a, 0x0b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x2d, 0x0a, 0x06, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x12, 0x0a, 0x0e, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x55, 0x4e,
+	0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x0f, 0x0a, 0x0b, 0x53, 0x54, 0x41, 0x54, 0x55,
+	0x53, 0x5f, 0x50, 0x41, 0x49, 0x44, 0x10, 0x01, 0x42, 0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c,
+	0x6f, 0x61, 0x64, 0x22, 0x30, 0x0a, 0x0f, 0x54, 0x65, 0x73, 0x74, 0x50, 0x72, 0x6f, 0x74, 0x6f,
+	0x53, 0x65, 0x63, 0x72, 0x65, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x3a, 0x07, 0x8a, 0x9a,
+	0x30, 0x03, 0x6d, 0x64, 0x35, 0x22, 0x31, 0x0a, 0x0f, 0x54, 0x65, 0x73, 0x74, 0x50, 0x72, 0x6f,
+	0x74, 0x6f, 0x48, 0x69, 0x64, 0x64, 0x65, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65,
+	0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x3a, 0x08,
+	0x8a, 0x9a, 0x30, 0x04, 0x6f, 0x6d, 0x69, 0x74, 0x22, 0xff, 0x01, 0x0a, 0x10, 0x54, 0x65, 0x73,
+	0x74, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x44, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x12, 0x12, 0x0a,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x12, 0x1c, 0x0a, 0x04, 0x63, 0x69, 0x74, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x42,
+	0x08, 0x8a, 0x9a, 0x30, 0x04, 0x6f, 0x6d, 0x69, 0x74, 0x52, 0x04, 0x63, 0x69, 0x74, 0x79, 0x12,
+	0x2d, 0x0a, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17,
+	0x2e, 0x54, 0x65, 0x73, 0x74, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x44, 0x65, 0x66, 0x61, 0x75, 0x6c,
+	0x74, 0x2e, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x28,
+	0x0a, 0x06, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10,
+	0x2e, 0x54, 0x65, 0x73, 0x74, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x53, 0x65, 0x63, 0x72, 0x65, 0x74,
+	0x52, 0x06, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x12, 0x28, 0x0a, 0x06, 0x68, 0x69, 0x64, 0x64,
+	0x65, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x54, 0x65, 0x73, 0x74, 0x50,
+	0x72, 0x6f, 0x74, 0x6f, 0x48, 0x69, 0x64, 0x64, 0x65, 0x6e, 0x52, 0x06, 0x68, 0x69, 0x64, 0x64,
+	0x65, 0x6e, 0x22, 0x2d, 0x0a, 0x05, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x0d, 0x0a, 0x09, 0x4c,
+	0x45, 0x56, 0x45, 0x4c, 0x5f, 0x4c, 0x4f, 0x57, 0x10, 0x00, 0x12, 0x15, 0x0a, 0x0b, 0x4c, 0x45,
+	0x56, 0x45, 0x4c, 0x5f, 0x46, 0x52, 0x41, 0x55, 0x44, 0x10, 0x01, 0x1a, 0x04, 0x88, 0x9a, 0x30,
+	0x01, 0x3a, 0x07, 0x92, 0x9a, 0x30, 0x03, 0x6d, 0x64, 0x35, 0x22, 0x78, 0x0a, 0x0d, 0x54, 0x65,
+	0x73, 0x74, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x20, 0x0a, 0x05, 0x70,
+	0x68, 0x6f, 0x6e, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x0a, 0x92, 0x9a, 0x30, 0x06,
+	0x08, 0x03, 0x10, 0x03, 0x18, 0x02, 0x52, 0x05, 0x70, 0x68, 0x6f, 0x6e, 0x65, 0x12, 0x26, 0x0a,
+	0x05, 0x65, 0x6d, 0x61, 0x69, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x42, 0x10, 0x8a, 0x9a,
+	0x30, 0x04, 0x6f, 0x6d, 0x69, 0x74, 0x92, 0x9a, 0x30, 0x04, 0x08, 0x02, 0x20, 0x01, 0x52, 0x05,
+	0x65, 0x6d, 0x61, 0x69, 0x6c, 0x12, 0x1d, 0x0a, 0x04, 0x6e, 0x6f, 0x74, 0x65, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x42, 0x09, 0x92, 0x9a, 0x30, 0x05, 0x2a, 0x03, 0x70, 0x69, 0x69, 0x52, 0x04,
+	0x6e, 0x6f, 0x74, 0x65, 0x42, 0x24, 0x5a, 0x22, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
+	0x6f, 0x6d, 0x2f, 0x65, 0x74, 0x68, 0x61, 0x6e, 0x76, 0x63, 0x2f, 0x6c, 0x6f, 0x67, 0x6a, 0x73,
+	0x6f, 0x6e, 0x3b, 0x6c, 0x6f, 0x67, 0x6a, 0x73, 0x6f, 0x6e,
 }
 
 var (
@@ -95,16 +705,42 @@ func file_test_proto_rawDescGZIP() []byte {
 	return file_test_proto_rawDescData
 }
 
-var file_test_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_test_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_test_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
 var file_test_proto_goTypes = []interface{}{
-	(*TestProtoAbc)(nil), // 0: TestProtoAbc
+	(TestProtoAll_Status)(0),       // 0: TestProtoAll.Status
+	(TestProtoDefault_Level)(0),    // 1: TestProtoDefault.Level
+	(*TestProtoAbc)(nil),           // 2: TestProtoAbc
+	(*TestProtoAll)(nil),           // 3: TestProtoAll
+	(*TestProtoSecret)(nil),        // 4: TestProtoSecret
+	(*TestProtoHidden)(nil),        // 5: TestProtoHidden
+	(*TestProtoDefault)(nil),       // 6: TestProtoDefault
+	(*TestProtoRule)(nil),          // 7: TestProtoRule
+	nil,                            // 8: TestProtoAll.CountsEntry
+	(*timestamppb.Timestamp)(nil),  // 9: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),    // 10: google.protobuf.Duration
+	(*anypb.Any)(nil),              // 11: google.protobuf.Any
+	(*structpb.Struct)(nil),        // 12: google.protobuf.Struct
+	(*wrapperspb.StringValue)(nil), // 13: google.protobuf.StringValue
 }
 var file_test_proto_depIdxs = []int32{
-	0, // [0:0] is the sub-list for method output_type
-	0, // [0:0] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0,  // 0: TestProtoAll.status:type_name -> TestProtoAll.Status
+	2,  // 1: TestProtoAll.abc:type_name -> TestProtoAbc
+	2,  // 2: TestProtoAll.items:type_name -> TestProtoAbc
+	8,  // 3: TestProtoAll.counts:type_name -> TestProtoAll.CountsEntry
+	9,  // 4: TestProtoAll.created_at:type_name -> google.protobuf.Timestamp
+	10, // 5: TestProtoAll.timeout:type_name -> google.protobuf.Duration
+	11, // 6: TestProtoAll.detail:type_name -> google.protobuf.Any
+	12, // 7: TestProtoAll.extra:type_name -> google.protobuf.Struct
+	13, // 8: TestProtoAll.nick:type_name -> google.protobuf.StringValue
+	1,  // 9: TestProtoDefault.level:type_name -> TestProtoDefault.Level
+	4,  // 10: TestProtoDefault.secret:type_name -> TestProtoSecret
+	5,  // 11: TestProtoDefault.hidden:type_name -> TestProtoHidden
+	12, // [12:12] is the sub-list for method output_type
+	12, // [12:12] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
 }
 
 func init() { file_test_proto_init() }
@@ -126,19 +762,84 @@ func file_test_proto_init() {
 				return nil
 			}
 		}
+		file_test_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TestProtoAll); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_test_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TestProtoSecret); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_test_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TestProtoHidden); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_test_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TestProtoDefault); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_test_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TestProtoRule); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_test_proto_msgTypes[1].OneofWrappers = []interface{}{
+		(*TestProtoAll_Text)(nil),
+		(*TestProtoAll_Abc)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_test_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   1,
+			NumEnums:      2,
+			NumMessages:   7,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_test_proto_goTypes,
 		DependencyIndexes: file_test_proto_depIdxs,
+		EnumInfos:         file_test_proto_enumTypes,
 		MessageInfos:      file_test_proto_msgTypes,
 	}.Build()
 	File_test_proto = out.File
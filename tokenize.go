@@ -0,0 +1,166 @@
+package logjson
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// TokenPrefix starts every token written by LogRuleTokenize.
+const TokenPrefix = "tok1:"
+
+// Tokenizer encrypts values deterministically with AES-SIV (RFC 5297), so equal
+// values map to equal tokens, and Detokenize recovers the value with the key.
+type Tokenizer struct {
+	macBlock cipher.Block
+	ctrBlock cipher.Block
+}
+
+// NewTokenizer creates a Tokenizer from a 32, 48 or 64 bytes key, which is
+// AES-SIV with AES-128, AES-192 or AES-256.
+func NewTokenizer(key []byte) (*Tokenizer, error) {
+	switch len(key) {
+	case 32, 48, 64:
+	default:
+		return nil, errors.New("logjson: tokenizer key must be 32, 48 or 64 bytes")
+	}
+	half := len(key) / 2
+	macBlock, err := aes.NewCipher(key[:half])
+	if err != nil {
+		return nil, err
+	}
+	ctrBlock, err := aes.NewCipher(key[half:])
+	if err != nil {
+		return nil, err
+	}
+	return &Tokenizer{
+		macBlock: macBlock,
+		ctrBlock: ctrBlock,
+	}, nil
+}
+
+// Tokenize returns the token of s.
+func (t *Tokenizer) Tokenize(s string) string {
+	out := t.seal([]byte(s))
+	return TokenPrefix + base64.RawURLEncoding.EncodeToString(out)
+}
+
+// Detokenize returns the value of a token created by Tokenize.
+func (t *Tokenizer) Detokenize(token string) (string, error) {
+	if !strings.HasPrefix(token, TokenPrefix) {
+		return "", errors.New("logjson: invalid token prefix")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token[len(TokenPrefix):])
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := t.open(data)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// LogRuleTokenize replaces string values with their token, see Tokenizer.
+// Values which are not strings are written as null.
+func LogRuleTokenize(t *Tokenizer) LogRule {
+	return func(conf *logRuleConf) {
+		conf.name = "tokenize"
+		conf.tokenizer = t
+	}
+}
+
+func (t *Tokenizer) seal(plaintext []byte, ad ...[]byte) []byte {
+	v := t.s2v(append(ad, plaintext)...)
+	out := make([]byte, aes.BlockSize+len(plaintext))
+	copy(out, v)
+	t.ctr(out[aes.BlockSize:], v, plaintext)
+	return out
+}
+
+func (t *Tokenizer) open(data []byte, ad ...[]byte) ([]byte, error) {
+	if len(data) < aes.BlockSize {
+		return nil, errors.New("logjson: token too short")
+	}
+	v := data[:aes.BlockSize]
+	plaintext := make([]byte, len(data)-aes.BlockSize)
+	t.ctr(plaintext, v, data[aes.BlockSize:])
+	expected := t.s2v(append(ad, plaintext)...)
+	if subtle.ConstantTimeCompare(expected, v) != 1 {
+		return nil, errors.New("logjson: token authentication failed")
+	}
+	return plaintext, nil
+}
+
+func (t *Tokenizer) ctr(dst, v, src []byte) {
+	q := make([]byte, aes.BlockSize)
+	copy(q, v)
+	q[8] &= 0x7f
+	q[12] &= 0x7f
+	cipher.NewCTR(t.ctrBlock, q).XORKeyStream(dst, src)
+}
+
+// s2v implements S2V of RFC 5297, the last item is the plaintext.
+func (t *Tokenizer) s2v(items ...[]byte) []byte {
+	d := t.cmac(make([]byte, aes.BlockSize))
+	for _, item := range items[:len(items)-1] {
+		sivDouble(d)
+		subtle.XORBytes(d, d, t.cmac(item))
+	}
+	last := items[len(items)-1]
+	var tmp []byte
+	if len(last) >= aes.BlockSize {
+		tmp = append([]byte(nil), last...)
+		subtle.XORBytes(tmp[len(tmp)-aes.BlockSize:], tmp[len(tmp)-aes.BlockSize:], d)
+	} else {
+		sivDouble(d)
+		tmp = make([]byte, aes.BlockSize)
+		copy(tmp, last)
+		tmp[len(last)] = 0x80
+		subtle.XORBytes(tmp, tmp, d)
+	}
+	return t.cmac(tmp)
+}
+
+// cmac implements AES-CMAC of RFC 4493 with the S2V key.
+func (t *Tokenizer) cmac(msg []byte) []byte {
+	k1 := make([]byte, aes.BlockSize)
+	t.macBlock.Encrypt(k1, k1)
+	sivDouble(k1)
+	k2 := append([]byte(nil), k1...)
+	sivDouble(k2)
+
+	n := (len(msg) + aes.BlockSize - 1) / aes.BlockSize
+	last := make([]byte, aes.BlockSize)
+	if n == 0 || len(msg)%aes.BlockSize != 0 {
+		if n == 0 {
+			n = 1
+		}
+		rest := msg[(n-1)*aes.BlockSize:]
+		copy(last, rest)
+		last[len(rest)] = 0x80
+		subtle.XORBytes(last, last, k2)
+	} else {
+		subtle.XORBytes(last, msg[(n-1)*aes.BlockSize:], k1)
+	}
+	x := make([]byte, aes.BlockSize)
+	for i := 0; i < n-1; i++ {
+		subtle.XORBytes(x, x, msg[i*aes.BlockSize:(i+1)*aes.BlockSize])
+		t.macBlock.Encrypt(x, x)
+	}
+	subtle.XORBytes(x, x, last)
+	t.macBlock.Encrypt(x, x)
+	return x
+}
+
+// sivDouble multiplies b by x in GF(2^128), which is dbl of RFC 5297.
+func sivDouble(b []byte) {
+	carry := b[0] >> 7
+	for i := 0; i < len(b)-1; i++ {
+		b[i] = b[i]<<1 | b[i+1]>>7
+	}
+	b[len(b)-1] = b[len(b)-1]<<1 ^ carry*0x87
+}
@@ -0,0 +1,73 @@
+package logjson
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTokenizer_Rfc5297Vector(t *testing.T) {
+	key, _ := hex.DecodeString("fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff")
+	ad, _ := hex.DecodeString("101112131415161718191a1b1c1d1e1f2021222324252627")
+	plaintext, _ := hex.DecodeString("112233445566778899aabbccddee")
+	tokenizer, err := NewTokenizer(key)
+	require.NoError(t, err)
+	out := tokenizer.seal(plaintext, ad)
+	require.Equal(t, "85632d07c6e8f37f950acd320a2ecc9340c02b9690c4dc04daef7f6afe5c", hex.EncodeToString(out))
+	opened, err := tokenizer.open(out, ad)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, opened)
+}
+
+func TestLogJson_Tokenize(t *testing.T) {
+	tokenizer, err := NewTokenizer(make([]byte, 32))
+	require.NoError(t, err)
+	type Abc struct {
+		Email string
+	}
+	j := NewLogJson()
+	j.AddLogRule("Email", LogRuleTokenize(tokenizer))
+	out1 := string(j.Marshal(Abc{Email: "a@b.com"}))
+	out2 := string(j.Marshal(Abc{Email: "a@b.com"}))
+	require.Equal(t, out1, out2)
+	require.NotContains(t, out1, "a@b.com")
+
+	token := tokenizer.Tokenize("a@b.com")
+	require.True(t, strings.HasPrefix(token, TokenPrefix))
+	require.Equal(t, `{"Email":"`+token+`"}`, out1)
+	s, err := tokenizer.Detokenize(token)
+	require.NoError(t, err)
+	require.Equal(t, "a@b.com", s)
+
+	_, err = tokenizer.Detokenize(token[:len(token)-2] + "AA")
+	require.Error(t, err)
+}
+
+func TestLogJson_TokenizeNonString(t *testing.T) {
+	tokenizer, err := NewTokenizer(make([]byte, 32))
+	require.NoError(t, err)
+	type Card struct {
+		Number string
+	}
+	type Abc struct {
+		Id    int64
+		Card  Card
+		Raw   []byte
+		Names []string
+	}
+	j := NewLogJson()
+	for _, name := range []string{"Id", "Card", "Raw", "Names"} {
+		j.AddLogRule(name, LogRuleTokenize(tokenizer))
+	}
+	require.Equal(t, `{"Id":null,"Card":null,"Raw":null,"Names":null}`,
+		string(j.Marshal(Abc{Id: 1, Card: Card{Number: "4111"}, Raw: []byte("raw"), Names: []string{"a"}})))
+
+	pj := NewLogJson()
+	pj.AddLogRule("id", LogRuleTokenize(tokenizer))
+	pj.AddLogRule("items", LogRuleTokenize(tokenizer))
+	require.Equal(t, `{"id":null,"items":null}`,
+		string(pj.Marshal(&TestProtoAll{Id: proto.Int64(1), Items: []*TestProtoAbc{{MyName: proto.String("a")}}})))
+}
@@ -0,0 +1,152 @@
+package logjson
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// typeAnalyzer caches the rule independent part of type analysis, so LogJson
+// views created by View do not repeat it.
+type typeAnalyzer struct {
+	structs sync.Map
+}
+
+type structInfo struct {
+	fields []structFieldInfo
+}
+
+type structFieldInfo struct {
+	field     reflect.StructField
+	Name      string
+	Index     []int
+	omitempty bool
+	tagConf   *logRuleConf
+	protoConf *logRuleConf
+	// typeConf is the rule from LogJSONRules of the struct declaring the
+	// field.
+	typeConf *logRuleConf
+	// embedded are the types of the embedded fields the field is promoted
+	// from, outermost first, pointers are dereferenced.
+	embedded []reflect.Type
+}
+
+func (a *typeAnalyzer) getStructInfo(t reflect.Type) *structInfo {
+	if tmp, ok := a.structs.Load(t); ok {
+		return tmp.(*structInfo)
+	}
+	info := newStructInfo(t)
+	if existInfo, loaded := a.structs.LoadOrStore(t, info); loaded {
+		return existInfo.(*structInfo)
+	}
+	return info
+}
+
+// newStructInfo analyzes the fields of t, promoted fields get the rules of
+// the struct declaring them, so the LogJSONRules of an embedded struct apply
+// to its fields only.
+func newStructInfo(t reflect.Type) *structInfo {
+	info := &structInfo{}
+	typeRules := make(map[reflect.Type]map[string]LogRule)
+	for _, field := range reflect.VisibleFields(t) {
+		if field.Anonymous {
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		embedded := embeddedTypes(t, field.Index)
+		declType := t
+		if len(embedded) > 0 {
+			declType = embedded[len(embedded)-1]
+		}
+		rules, ok := typeRules[declType]
+		if !ok {
+			rules = getTypeLogRules(declType)
+			typeRules[declType] = rules
+		}
+		f := newStructFieldInfo(declType, field)
+		f.embedded = embedded
+		if rule, ok := rules[f.Name]; ok {
+			f.typeConf = newLogRuleConf(rule)
+		} else if rule, ok := rules["*"]; ok {
+			f.typeConf = newLogRuleConf(rule)
+		}
+		info.fields = append(info.fields, f)
+	}
+	return info
+}
+
+// embeddedTypes returns the types of the embedded fields on the path index of
+// a field of t, the last one declares the field.
+func embeddedTypes(t reflect.Type, index []int) []reflect.Type {
+	var types []reflect.Type
+	for _, i := range index[:len(index)-1] {
+		t = t.Field(i).Type
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		types = append(types, t)
+	}
+	return types
+}
+
+var logRulesProviderIntType = reflect.TypeFor[LogRulesProvider]()
+
+// getTypeLogRules returns the rules declared by LogJSONRules of t. The method
+// must be declared by t or *t, a method promoted from an embedded field
+// declares the rules of the embedded struct, not of t.
+func getTypeLogRules(t reflect.Type) map[string]LogRule {
+	if t.Implements(logRulesProviderIntType) && !isPromotedMethod(t, "LogJSONRules") {
+		return reflect.Zero(t).Interface().(LogRulesProvider).LogJSONRules()
+	}
+	if reflect.PointerTo(t).Implements(logRulesProviderIntType) && !isPromotedMethod(reflect.PointerTo(t), "LogJSONRules") {
+		return reflect.New(t).Interface().(LogRulesProvider).LogJSONRules()
+	}
+	return nil
+}
+
+// isPromotedMethod reports whether the method name of t is promoted from an
+// embedded field. The compiler generates wrappers for promoted methods, which
+// have no source file. The methods of value receivers in the method set of
+// pointers are wrappers too, so they are reported as promoted.
+func isPromotedMethod(t reflect.Type, name string) bool {
+	m, ok := t.MethodByName(name)
+	if !ok {
+		return false
+	}
+	pc := m.Func.Pointer()
+	file, _ := runtime.FuncForPC(pc).FileLine(pc)
+	return file == "<autogenerated>"
+}
+
+func newStructFieldInfo(parentType reflect.Type, field reflect.StructField) structFieldInfo {
+	f := structFieldInfo{
+		field: field,
+		Name:  field.Name,
+		Index: field.Index,
+	}
+	f.initJsonTag(field)
+	f.tagConf = newLogRuleConfFromStr(field.Tag.Get("log"))
+	if f.tagConf == nil {
+		f.protoConf = getFieldOptionFromType(parentType, f.Name)
+	}
+	return f
+}
+
+func (f *structFieldInfo) initJsonTag(field reflect.StructField) {
+	parts := strings.Split(field.Tag.Get("json"), ",")
+	for i, part := range parts {
+		if i == 0 {
+			if part != "" {
+				f.Name = part
+			}
+			continue
+		}
+		switch part {
+		case "omitempty":
+			f.omitempty = true
+		}
+	}
+}
@@ -2,6 +2,7 @@ package zaplogjson
 
 import (
 	"bytes"
+	"github.com/ethanvc/logjson"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -33,3 +34,15 @@ func newTestZapLogger() (*zap.Logger, *bytes.Buffer) {
 	logger := zap.New(core)
 	return logger, buf
 }
+
+func TestNewLogJsonEncoder_Secret(t *testing.T) {
+	logger, buf := newTestZapLogger()
+	type Abc struct {
+		Name     string
+		Password logjson.Secret[string]
+	}
+	logger.Info("Test", zap.Any("test", Abc{Name: "hello", Password: logjson.NewSecret("password")}),
+		zap.Any("secret", logjson.NewSecret("password")))
+	require.Equal(t, `{"level":"info","msg":"Test","test":{"Name":"hello","Password":"[REDACTED]"},"secret":"[REDACTED]"}`+"\n", buf.String())
+	require.NotContains(t, buf.String(), "password")
+}